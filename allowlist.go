@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	defaultFallbackMsg = "sorry, you are not on the guest list"
+	defaultMatchMin    = 0.8
+)
+
+// allowlist maps normalized names to the entry in the file, so
+// "benny  ENGSTROM" is greeted as "Benny Engstrom".
+type allowlist map[string]guest
+
+// guest is an allowlist entry. A line may give a pronunciation after a
+// bar, as in "Siobhan Byrne | shi-VAWN BURN".
+type guest struct {
+	name          string
+	pronunciation string
+}
+
+func loadAllowlist(path, enc string) (allowlist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read allowlist: %w", err)
+	}
+	defer f.Close()
+
+	a := allowlist{}
+	scanner := bufio.NewScanner(decodeInput(f, enc))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		var g guest
+		if i := strings.Index(line, "|"); i >= 0 {
+			line, g.pronunciation = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		g.name = strings.Join(strings.Fields(line), " ")
+		if g.name == "" {
+			continue
+		}
+		a[normalizeName(g.name)] = g
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read allowlist: %w", err)
+	}
+	return a, nil
+}
+
+func (a allowlist) lookup(name string) (string, bool) {
+	listed, ok := a[normalizeName(name)]
+	return listed.name, ok
+}
+
+// closest returns the listed name most similar to name along with a
+// similarity score between 0 and 1.
+func (a allowlist) closest(name string) (string, float64) {
+	n := normalizeName(name)
+	best, bestScore := "", 0.0
+	for key, g := range a {
+		listed := g.name
+		score := similarity(n, key)
+		// ties go to the alphabetically first name so results are stable
+		if score > bestScore || (score == bestScore && listed < best) {
+			best, bestScore = listed, score
+		}
+	}
+	return best, bestScore
+}
+
+// checkAllowlist returns the listed spelling of name. A name that isn't on
+// the list but is close enough to an entry is offered as a suggestion,
+// which the user has to confirm. When the name was given with --name,
+// comes from a batch or is piped in with prompts off, there is nobody to
+// ask and the suggestion is used as is.
+func checkAllowlist(scanner *bufio.Scanner, w io.Writer, c config, name string) (string, error) {
+	a, err := loadAllowlist(c.allowlistFile, c.inputEncoding)
+	if err != nil {
+		return "", err
+	}
+	if listed, ok := a.lookup(name); ok {
+		return listed, nil
+	}
+
+	matchMin := c.matchMin
+	if matchMin == 0 {
+		matchMin = defaultMatchMin
+	}
+	suggestion, score := a.closest(name)
+	if suggestion == "" || score < matchMin {
+		return "", notAllowedError(c)
+	}
+	if !c.interactive() || c.noPrompt {
+		return suggestion, nil
+	}
+
+	m := c.messages()
+	fmt.Fprintf(w, m.didYouMean+"\n", suggestion)
+	scanner.Scan()
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if !m.confirms(scanner.Text()) {
+		return "", notAllowedError(c)
+	}
+	return suggestion, nil
+}
+
+// pronunciation returns how the listed name is pronounced, or "" if the
+// allowlist doesn't say.
+func pronunciation(c config, name string) (string, error) {
+	a, err := loadAllowlist(c.allowlistFile, c.inputEncoding)
+	if err != nil {
+		return "", err
+	}
+	return a[normalizeName(name)].pronunciation, nil
+}
+
+// normalizeName lower-cases name and collapses any runs of whitespace.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func notAllowedError(c config) error {
+	if c.fallbackMsg != "" {
+		return inputError{errors.New(c.fallbackMsg)}
+	}
+	return inputError{errors.New(defaultFallbackMsg)}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAllowlistLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guests.txt")
+	if err := os.WriteFile(path, []byte("# guests\nBenny Engstrom\n\n  Ada   Lovelace \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := loadAllowlist(path, "")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+
+	tests := []struct {
+		name   string
+		listed string
+		ok     bool
+	}{
+		{name: "Benny Engstrom", listed: "Benny Engstrom", ok: true},
+		{name: "  benny   ENGSTROM ", listed: "Benny Engstrom", ok: true},
+		{name: "ada lovelace", listed: "Ada Lovelace", ok: true},
+		{name: "Charles Babbage", listed: "", ok: false},
+		{name: "# guests", listed: "", ok: false},
+	}
+
+	for _, tc := range tests {
+		listed, ok := a.lookup(tc.name)
+		if ok != tc.ok {
+			t.Errorf("expected %q to be allowed: %v, got: %v\n", tc.name, tc.ok, ok)
+		}
+		if listed != tc.listed {
+			t.Errorf("expected %q to be listed as: %q, got: %q\n", tc.name, tc.listed, listed)
+		}
+	}
+}
+
+func TestAllowlistClosest(t *testing.T) {
+	a := allowlist{"benny engstrom": {name: "Benny Engstrom"}, "jenny engstrom": {name: "Jenny Engstrom"}, "ada lovelace": {name: "Ada Lovelace"}}
+
+	listed, score := a.closest("Beny Engstrom")
+	if listed != "Benny Engstrom" {
+		t.Errorf("expected closest name to be: Benny Engstrom, got: %v\n", listed)
+	}
+	if score < defaultMatchMin {
+		t.Errorf("expected score above %v, got: %v\n", defaultMatchMin, score)
+	}
+
+	// equally close entries are resolved alphabetically
+	listed, _ = a.closest("enny engstrom")
+	if listed != "Benny Engstrom" {
+		t.Errorf("expected tie to resolve to: Benny Engstrom, got: %v\n", listed)
+	}
+}
+
+func TestLoadAllowlistMissingFile(t *testing.T) {
+	_, err := loadAllowlist(filepath.Join(t.TempDir(), "missing.txt"), "")
+	if err == nil {
+		t.Fatal("expected an error for a missing allowlist file, got nil")
+	}
+}
+
+func TestRunCmdAllowlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guests.txt")
+	if err := os.WriteFile(path, []byte("Benny Engstrom\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prompt := "Your name please? Press the return key when done.\n"
+
+	tests := []struct {
+		c      config
+		input  string
+		output string
+		err    string
+	}{
+		{
+			c:      config{numTimes: 2, allowlistFile: path},
+			input:  "benny engstrom",
+			output: prompt + "Nice to meet you Benny Engstrom\nNice to meet you Benny Engstrom\n",
+		},
+		{
+			c:      config{numTimes: 2, allowlistFile: path},
+			input:  "Ada Lovelace",
+			output: prompt,
+			err:    defaultFallbackMsg,
+		},
+		{
+			c:      config{numTimes: 2, allowlistFile: path, fallbackMsg: "Welcome, guest!"},
+			input:  "Ada Lovelace",
+			output: prompt,
+			err:    "Welcome, guest!",
+		},
+		{
+			c:      config{numTimes: 1, allowlistFile: path},
+			input:  "Beny Engstrom\ny\n",
+			output: prompt + "Did you mean Benny Engstrom? [y/N]\nNice to meet you Benny Engstrom\n",
+		},
+		{
+			c:      config{numTimes: 1, allowlistFile: path},
+			input:  "Beny Engstrom\nn\n",
+			output: prompt + "Did you mean Benny Engstrom? [y/N]\n",
+			err:    defaultFallbackMsg,
+		},
+		{
+			c:      config{numTimes: 1, allowlistFile: path, lang: "de"},
+			input:  "Beny Engstrom\nj\n",
+			output: "Wie heißt du? Drücke die Eingabetaste, wenn du fertig bist.\nMeintest du Benny Engstrom? [j/N]\nSchön, dich kennenzulernen, Benny Engstrom\n",
+		},
+		{
+			// or when the names are piped in without prompts, where the
+			// answer would be taken from the next name
+			c:      config{numTimes: 1, allowlistFile: path, noPrompt: true},
+			input:  "Beny Engstrom\nAda Lovelace\n",
+			output: "Nice to meet you Benny Engstrom\n",
+		},
+		{
+			// nobody to confirm the suggestion with when the name is a flag
+			c:      config{numTimes: 1, allowlistFile: path, name: "Beny Engstrom"},
+			output: "Nice to meet you Benny Engstrom\n",
+		},
+		{
+			c:      config{numTimes: 1, allowlistFile: path, matchMin: 1},
+			input:  "Beny Engstrom\ny\n",
+			output: prompt,
+			err:    defaultFallbackMsg,
+		},
+	}
+
+	byteBuf := new(bytes.Buffer)
+	for _, tc := range tests {
+		err := runCmd(context.Background(), strings.NewReader(tc.input), byteBuf, tc.c)
+		if tc.err == "" && err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Fatalf("expected error: %v, got error: %v\n", tc.err, err)
+		}
+		if got := byteBuf.String(); got != tc.output {
+			t.Errorf("expected stdout message to be: %v, got: %v\n", tc.output, got)
+		}
+		byteBuf.Reset()
+	}
+}
+
+func TestAllowlistPronunciation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guests.txt")
+	if err := os.WriteFile(path, []byte("Siobhan  Byrne | shi-VAWN BURN \nBenny Engstrom\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{input: "siobhan byrne\n", output: "Nice to meet you Siobhan Byrne\n(pronounced shi-VAWN BURN)\n"},
+		{input: "Benny Engstrom\n", output: "Nice to meet you Benny Engstrom\n"},
+	}
+
+	for _, tc := range tests {
+		c := withDefaults(config{numTimes: 1, allowlistFile: path, pronounce: true})
+		if err := validateArgs(c); err != nil {
+			t.Fatal(err)
+		}
+		byteBuf := new(bytes.Buffer)
+		if err := runCmd(context.Background(), strings.NewReader(tc.input), byteBuf, c); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		output := strings.TrimPrefix(byteBuf.String(), "Your name please? Press the return key when done.\n")
+		if output != tc.output {
+			t.Errorf("expected output %q, got: %q\n", tc.output, output)
+		}
+	}
+
+	if err := validateArgs(withDefaults(config{numTimes: 1, pronounce: true})); err == nil {
+		t.Error("expected --show-pronunciation without --allowlist to be rejected")
+	}
+}
+
+func TestConfirms(t *testing.T) {
+	tests := []struct {
+		lang    string
+		answers []string
+		refuses []string
+	}{
+		{lang: "en", answers: []string{"y", "Yes", " yes "}, refuses: []string{"", "n", "no", "yeah"}},
+		{lang: "de", answers: []string{"j", "Ja", "y"}, refuses: []string{"", "n", "nein"}},
+		{lang: "es", answers: []string{"s", "sí", "yes"}, refuses: []string{"", "n", "no"}},
+		{lang: "fr", answers: []string{"o", "oui", "y"}, refuses: []string{"", "n", "non"}},
+		{lang: "sv", answers: []string{"j", "ja", "yes"}, refuses: []string{"", "n", "nej"}},
+	}
+
+	for _, tc := range tests {
+		m := catalogs[tc.lang]
+		for _, a := range tc.answers {
+			if !m.confirms(a) {
+				t.Errorf("%s: expected %q to confirm\n", tc.lang, a)
+			}
+		}
+		for _, a := range tc.refuses {
+			if m.confirms(a) {
+				t.Errorf("%s: expected %q not to confirm\n", tc.lang, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"text/tabwriter"
+)
+
+// The fonts and word lists the tool comes with are embedded from assets/.
+// Each can be replaced without rebuilding by a file at the same path
+// under an override directory. These are searched in order, the first
+// that has the file wins:
+//
+//  1. the directories in $NAME_CLI_ASSETS, separated like $PATH
+//  2. assets in userConfigDir, e.g. ~/.config/name-cli/assets
+//
+// name-cli assets export writes the built-in ones out to start from.
+
+//go:embed assets
+var builtinAssets embed.FS
+
+const assetsEnv = "NAME_CLI_ASSETS"
+
+// assetDirs are the override directories, in the order they're searched.
+func assetDirs() []string {
+	dirs := []string{}
+	for _, dir := range filepath.SplitList(os.Getenv(assetsEnv)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if dir := userConfigDir(); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, "assets"))
+	}
+	return dirs
+}
+
+// assetNames lists the built-in assets, as slash separated paths under
+// assets/ like "fonts/block.font".
+func assetNames() []string {
+	names := []string{}
+	fs.WalkDir(builtinAssets, "assets", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			names = append(names, p[len("assets/"):])
+		}
+		return err
+	})
+	return names
+}
+
+// assetPath returns the file overriding the asset called name, or "" if
+// the built-in one is used.
+func assetPath(name string) string {
+	for _, dir := range assetDirs() {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// readAsset returns the asset called name from the first override
+// directory that has it, or the built-in copy.
+func readAsset(name string) ([]byte, error) {
+	if p := assetPath(name); p != "" {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not read asset: %w", err)
+		}
+		return data, nil
+	}
+	return builtinAssets.ReadFile(path.Join("assets", name))
+}
+
+func newAssetsFlagSet(c *config) *flag.FlagSet {
+	fs := newHelpFlagSet(c)
+	fs.BoolVar(&c.force, "force", c.force, "with export, replace files that are already there")
+	return fs
+}
+
+// parseAssetsArgs parses "assets list" and "assets export [dir]", options
+// may come before or after the action.
+func parseAssetsArgs(args []string) (config, error) {
+	c := config{command: "assets"}
+	fs := newAssetsFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	rest := fs.Args()
+	if len(rest) > 0 {
+		c.assetsAction = rest[0]
+		if err := fs.Parse(rest[1:]); err != nil {
+			return config{}, err
+		}
+		rest = fs.Args()
+	}
+	if c.printUsage {
+		return config{printUsage: true, command: "assets"}, nil
+	}
+
+	switch c.assetsAction {
+	case "list":
+		if len(rest) != 0 {
+			return config{}, ErrInvalidArgCount
+		}
+	case "export":
+		if len(rest) > 1 {
+			return config{}, ErrInvalidArgCount
+		}
+		if len(rest) == 1 {
+			c.assetsDir = rest[0]
+		}
+	case "":
+		return config{}, errors.New("expected list or export")
+	default:
+		return config{}, fmt.Errorf("unknown action %q, expected list or export", c.assetsAction)
+	}
+	return c, nil
+}
+
+func runAssets(w io.Writer, c config) error {
+	if c.assetsAction == "list" {
+		return listAssets(w)
+	}
+	dir := c.assetsDir
+	if dir == "" {
+		if dir = userConfigDir(); dir == "" {
+			return errors.New("no home directory to export to, give a directory")
+		}
+		dir = filepath.Join(dir, "assets")
+	}
+	return exportAssets(w, dir, c.force)
+}
+
+// listAssets writes each asset with the file overriding it, if any, and
+// the directories searched.
+func listAssets(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range assetNames() {
+		from := "built-in"
+		if p := assetPath(name); p != "" {
+			from = p
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", name, from)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "\nOverride directories, searched in order:")
+	for _, dir := range assetDirs() {
+		fmt.Fprintf(w, "  %s\n", dir)
+	}
+	return nil
+}
+
+// exportAssets writes the built-in assets under dir, leaving files that
+// are already there unless force is set.
+func exportAssets(w io.Writer, dir string, force bool) error {
+	for _, name := range assetNames() {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(p); err == nil && !force {
+			return fmt.Errorf("%s already exists, use --force to replace it", p)
+		}
+	}
+	for _, name := range assetNames() {
+		data, err := builtinAssets.ReadFile(path.Join("assets", name))
+		if err != nil {
+			return err
+		}
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return fmt.Errorf("could not export assets: %w", err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			return fmt.Errorf("could not export assets: %w", err)
+		}
+		fmt.Fprintf(w, "Wrote %s\n", p)
+	}
+	return nil
+}
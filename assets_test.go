@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAssetsArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+		c    config
+	}{
+		{
+			args: []string{"list"},
+			c:    config{command: "assets", assetsAction: "list"},
+		},
+		{
+			args: []string{"export", "--force", "mine"},
+			c:    config{command: "assets", assetsAction: "export", assetsDir: "mine", force: true},
+		},
+		{
+			args: []string{"--force", "export"},
+			c:    config{command: "assets", assetsAction: "export", force: true},
+		},
+		{
+			args: []string{"export", "-h"},
+			c:    config{command: "assets", printUsage: true},
+		},
+		{
+			args: []string{},
+			err:  errors.New("expected list or export"),
+		},
+		{
+			args: []string{"import"},
+			err:  errors.New(`unknown action "import", expected list or export`),
+		},
+		{
+			args: []string{"list", "extra"},
+			err:  ErrInvalidArgCount,
+		},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"assets"}, tc.args...))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("%v: expected error to be: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("%v: expected nil error, got: %v\n", tc.args, err)
+		}
+		if !reflect.DeepEqual(c, tc.c) {
+			t.Errorf("%v: expected config to be: %+v, got: %+v\n", tc.args, tc.c, c)
+		}
+	}
+}
+
+func TestReadAssetOverride(t *testing.T) {
+	home, first := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv(assetsEnv, "")
+
+	builtin, err := readAsset("denylist.txt")
+	if err != nil || len(builtin) == 0 {
+		t.Fatalf("expected the built-in denylist, got: %v\n", err)
+	}
+
+	// the config directory overrides the built-in copy
+	userDir := filepath.Join(home, "name-cli", "assets")
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(userDir, "denylist.txt"), []byte("user\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := readAsset("denylist.txt"); string(data) != "user\n" {
+		t.Errorf("expected the override from the config directory, got: %q\n", data)
+	}
+
+	// and $NAME_CLI_ASSETS overrides that
+	if err := os.WriteFile(filepath.Join(first, "denylist.txt"), []byte("env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(assetsEnv, first+string(os.PathListSeparator)+t.TempDir())
+	if data, _ := readAsset("denylist.txt"); string(data) != "env\n" {
+		t.Errorf("expected the override from $%s, got: %q\n", assetsEnv, data)
+	}
+	d, err := loadDenylist("", "")
+	if err != nil || !d["env"] || len(d) != 1 {
+		t.Errorf("expected the denylist to come from the override, got: %v, %v\n", d, err)
+	}
+}
+
+func TestLoadFontOverride(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(assetsEnv, dir)
+	if f, err := loadFont("block"); err != nil || !reflect.DeepEqual(f, blockFont) {
+		t.Errorf("expected the built-in font, got: %v\n", err)
+	}
+
+	path := filepath.Join(dir, "fonts", "block"+fontExt)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte("height 1\n:a\n#@\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := loadFont("block")
+	if err != nil || f.height != 1 {
+		t.Errorf("expected the overriding font, got: %+v, %v\n", f, err)
+	}
+
+	os.WriteFile(path, []byte("oops\n"), 0644)
+	if _, err := loadFont("block"); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("expected an error naming %v, got: %v\n", path, err)
+	}
+}
+
+func TestExportAssets(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	if err := exportAssets(&out, dir, false); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	for _, name := range assetNames() {
+		builtin, _ := builtinAssets.ReadFile("assets/" + name)
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || !bytes.Equal(data, builtin) {
+			t.Errorf("%s: expected the built-in copy, got: %v\n", name, err)
+		}
+	}
+	if strings.Count(out.String(), "Wrote ") != len(assetNames()) {
+		t.Errorf("expected a line per file, got: %q\n", out.String())
+	}
+
+	// an edited file isn't replaced unless asked to
+	edited := filepath.Join(dir, "denylist.txt")
+	os.WriteFile(edited, []byte("mine\n"), 0644)
+	err := exportAssets(new(bytes.Buffer), dir, false)
+	if err == nil || err.Error() != edited+" already exists, use --force to replace it" {
+		t.Errorf("expected an error about %v, got: %v\n", edited, err)
+	}
+	if data, _ := os.ReadFile(edited); string(data) != "mine\n" {
+		t.Errorf("expected the edited file to be kept, got: %q\n", data)
+	}
+	if err := exportAssets(new(bytes.Buffer), dir, true); err != nil {
+		t.Errorf("expected nil error with force, got: %v\n", err)
+	}
+}
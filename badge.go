@@ -0,0 +1,187 @@
+//go:build !nobadge
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// hasBadge is false in builds with -tags nobadge, see nobadge.go.
+const hasBadge = true
+
+const (
+	defaultBadgeFile   = "badge.pdf"
+	defaultBadgeLayout = "standard"
+	// courierWidth is the advance of every Courier glyph, in font units
+	// of the text size.
+	courierWidth = 0.6
+)
+
+// badgeLayout describes where things go on a badge, sizes in points.
+type badgeLayout struct {
+	width, height float64
+	margin        float64
+	header        string
+	headerSize    float64
+	// nameSize is the largest size the name is drawn at, long names are
+	// shrunk to fit
+	nameSize float64
+	qrSize   float64
+}
+
+var badgeLayouts = map[string]badgeLayout{
+	// 4x3 inch, the usual conference badge
+	"standard": {width: 288, height: 216, margin: 12, header: "HELLO my name is", headerSize: 16, nameSize: 40, qrSize: 72},
+	// business card size, for card holders
+	"small": {width: 252, height: 144, margin: 9, header: "HELLO my name is", headerSize: 11, nameSize: 28, qrSize: 54},
+}
+
+func badgeLayoutNames() []string {
+	names := []string{}
+	for name := range badgeLayouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func badgeDefaults() config {
+	c := defaultConfig()
+	c.command = "badge"
+	c.badgeFile = defaultBadgeFile
+	c.badgeLayout = defaultBadgeLayout
+	return c
+}
+
+func newBadgeFlagSet(c *config) *flag.FlagSet {
+	fs := flag.NewFlagSet("badge", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&c.printUsage, "help", c.printUsage, "show this help and exit")
+	fs.BoolVar(&c.printUsage, "h", c.printUsage, "show this help and exit")
+	fs.StringVar(&c.name, "name", c.name, "`name` to put on the badge")
+	fs.StringVar(&c.contact, "contact", c.contact, "contact details to encode in a QR code, e.g. a `url` or mailto: link")
+	fs.StringVar(&c.badgeFile, "out", c.badgeFile, "write the badge to `file.pdf`")
+	fs.StringVar(&c.badgeLayout, "layout", c.badgeLayout, "badge size and layout, `"+strings.Join(badgeLayoutNames(), "|")+"`")
+	addNameCheckFlags(fs, c)
+	return fs
+}
+
+func parseBadgeArgs(args []string) (config, error) {
+	c := badgeDefaults()
+	fs := newBadgeFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return config{printUsage: true, command: "badge"}, nil
+	}
+	if err := resolveEnv(fs, &c); err != nil {
+		return config{}, err
+	}
+	if fs.NArg() != 0 {
+		return config{}, ErrInvalidArgCount
+	}
+	if c.denylistFile != "" && c.filterMode == "" {
+		c.filterMode = filterReject
+	}
+	return c, nil
+}
+
+func validateBadgeArgs(c config) error {
+	if strings.TrimSpace(c.name) == "" {
+		return errors.New("--name is required")
+	}
+	if c.badgeFile == "" {
+		return errors.New("--out must not be empty")
+	}
+	if _, ok := badgeLayouts[c.badgeLayout]; !ok {
+		return fmt.Errorf("unknown layout %q, expected one of: %s", c.badgeLayout, strings.Join(badgeLayoutNames(), ", "))
+	}
+	return validateNameChecks(c)
+}
+
+// badgeContent draws the badge: a dark header band, the name as large as
+// fits and the QR code, if any, in the bottom right corner.
+func badgeContent(l badgeLayout, name string, qr *qrCode) string {
+	var b strings.Builder
+	band := l.headerSize * 2.2
+
+	fmt.Fprintf(&b, "0.5 w %s %s %s %s re S\n", pdfNum(l.margin/2), pdfNum(l.margin/2), pdfNum(l.width-l.margin), pdfNum(l.height-l.margin))
+	fmt.Fprintf(&b, "0 g %s %s %s %s re f\n", pdfNum(l.margin/2), pdfNum(l.height-l.margin/2-band), pdfNum(l.width-l.margin), pdfNum(band))
+	header := pdfText(l.header)
+	fmt.Fprintf(&b, "1 g BT /F2 %s Tf %s %s Td %s Tj ET\n",
+		pdfNum(l.headerSize),
+		pdfNum((l.width-courierWidth*l.headerSize*float64(len(header)))/2),
+		pdfNum(l.height-l.margin/2-band/2-l.headerSize/3),
+		pdfString(header))
+
+	avail := l.width - 2*l.margin
+	if qr != nil {
+		avail -= l.qrSize
+	}
+	text := pdfText(name)
+	size := l.nameSize
+	if w := courierWidth * size * float64(len(text)); w > avail {
+		size *= avail / w
+	}
+	top := l.height - l.margin/2 - band
+	fmt.Fprintf(&b, "0 g BT /F2 %s Tf %s %s Td %s Tj ET\n",
+		pdfNum(size),
+		pdfNum(l.margin+(avail-courierWidth*size*float64(len(text)))/2),
+		pdfNum(top/2-size/3),
+		pdfString(text))
+
+	if qr != nil {
+		// leave the quiet zone of four modules around the code
+		m := l.qrSize / float64(qr.size+8)
+		x0, y0 := l.width-l.margin-l.qrSize, l.margin
+		for y := 0; y < qr.size; y++ {
+			for x := 0; x < qr.size; x++ {
+				if qr.modules[y][x] {
+					fmt.Fprintf(&b, "%s %s %s %s re\n", pdfNum(x0+float64(x+4)*m), pdfNum(y0+l.qrSize-float64(y+5)*m), pdfNum(m), pdfNum(m))
+				}
+			}
+		}
+		b.WriteString("f\n")
+	}
+	return b.String()
+}
+
+// runBadge runs the name through the same checks as a greeting and
+// writes the badge for it.
+func runBadge(w io.Writer, c config) error {
+	c.name = strings.Join(strings.Fields(c.name), " ")
+	name, err := checkName(bufio.NewScanner(strings.NewReader("")), w, c)
+	if err != nil {
+		return err
+	}
+
+	var qr *qrCode
+	if c.contact != "" {
+		if qr, err = qrEncode([]byte(c.contact)); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(c.badgeFile)
+	if err != nil {
+		return err
+	}
+	l := badgeLayouts[c.badgeLayout]
+	if err := writePDF(f, l.width, l.height, badgeContent(l, name, qr)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Wrote the badge for %s to %s\n", name, c.badgeFile)
+	return nil
+}
@@ -0,0 +1,138 @@
+//go:build !nobadge
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseBadgeArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+		c    config
+	}{
+		{
+			args: []string{"--name", "Benny"},
+			c:    config{command: "badge", name: "Benny", badgeFile: defaultBadgeFile, badgeLayout: defaultBadgeLayout},
+		},
+		{
+			args: []string{"--name", "Benny", "--contact", "mailto:benny@example.com", "--out", "b.pdf", "--layout", "small"},
+			c:    config{command: "badge", name: "Benny", contact: "mailto:benny@example.com", badgeFile: "b.pdf", badgeLayout: "small"},
+		},
+		{
+			args: []string{"-h"},
+			c:    config{command: "badge", printUsage: true},
+		},
+		{
+			args: []string{"Benny"},
+			err:  ErrInvalidArgCount,
+		},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"badge"}, tc.args...))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("expected error to be: %v, got: %v\n", tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if c.command != tc.c.command || c.name != tc.c.name || c.contact != tc.c.contact || c.badgeFile != tc.c.badgeFile || c.badgeLayout != tc.c.badgeLayout || c.printUsage != tc.c.printUsage {
+			t.Errorf("expected config to be: %+v, got: %+v\n", tc.c, c)
+		}
+	}
+}
+
+func TestValidateBadgeArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+	}{
+		{args: []string{"--name", "Benny"}},
+		{args: []string{}, err: errors.New("--name is required")},
+		{args: []string{"--name", " "}, err: errors.New("--name is required")},
+		{args: []string{"--name", "Benny", "--layout", "huge"}, err: errors.New(`unknown layout "huge", expected one of: small, standard`)},
+		{args: []string{"--name", "Benny", "--out", ""}, err: errors.New("--out must not be empty")},
+	}
+
+	for _, tc := range tests {
+		c, err := parseBadgeArgs(tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = validateArgs(c)
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Errorf("%v: expected error to be: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Errorf("%v: expected nil error, got: %v\n", tc.args, err)
+		}
+	}
+}
+
+func TestBadgeContent(t *testing.T) {
+	l := badgeLayouts[defaultBadgeLayout]
+	content := badgeContent(l, "Benny", nil)
+	if !strings.Contains(content, "(Benny) Tj") || !strings.Contains(content, "(HELLO my name is) Tj") {
+		t.Errorf("expected the header and the name, got:\n%s", content)
+	}
+	if !strings.Contains(content, "/F2 40 Tf") {
+		t.Errorf("expected a short name at full size, got:\n%s", content)
+	}
+	if strings.Contains(content, "\nf\n") {
+		t.Errorf("expected no QR code without contact details, got:\n%s", content)
+	}
+
+	// a long name shrinks to fit beside the QR code
+	qr, err := qrEncode([]byte("mailto:benny@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content = badgeContent(l, "Bartholomew Featherstonehaugh", qr)
+	if strings.Contains(content, "/F2 40 Tf") || !strings.HasSuffix(content, " re\nf\n") {
+		t.Errorf("expected a smaller name and a QR code, got:\n%s", content)
+	}
+}
+
+func TestRunBadge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "badge.pdf")
+	c, err := parseBadgeArgs([]string{"--name", "  Benny   Engstrom ", "--contact", "mailto:benny@example.com", "--out", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := runBadge(&out, c); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if expected := "Wrote the badge for Benny Engstrom to " + path + "\n"; out.String() != expected {
+		t.Errorf("expected %q, got: %q\n", expected, out.String())
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("%PDF-")) || !bytes.Contains(b, []byte("(Benny Engstrom) Tj")) {
+		t.Errorf("expected a PDF badge, got:\n%s", b)
+	}
+
+	// names go through the same checks as greetings
+	c, _ = parseBadgeArgs([]string{"--name", "Grumpy", "--denylist", writeDenylist(t, "grumpy"), "--out", path})
+	if err := runBadge(&out, c); err == nil || err.Error() != "that name is not allowed" {
+		t.Errorf("expected the name to be rejected, got: %v\n", err)
+	}
+}
+
+func writeDenylist(t *testing.T, words string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte(words+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
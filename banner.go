@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// font is a set of glyphs drawn with characters, all of the same height.
+type font struct {
+	height int
+	glyphs map[rune][]string
+}
+
+// defaultFont is the font of --banner unless --font says otherwise.
+const defaultFont = "block"
+
+// fontExt is the extension of font files. The format is our own, see
+// parseFont, so it isn't FIGlet's .flf.
+const fontExt = ".font"
+
+func fontAsset(name string) string {
+	return "fonts/" + name + fontExt
+}
+
+// fontNames lists the built-in fonts.
+func fontNames() []string {
+	names := []string{}
+	for _, name := range assetNames() {
+		if strings.HasPrefix(name, "fonts/") && strings.HasSuffix(name, fontExt) {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(name, "fonts/"), fontExt))
+		}
+	}
+	return names
+}
+
+// validateFont checks that --font names a built-in font or one in an
+// assets directory.
+func validateFont(name string) error {
+	asset := fontAsset(name)
+	if _, err := builtinAssets.ReadFile("assets/" + asset); err == nil || assetPath(asset) != "" {
+		return nil
+	}
+	return fmt.Errorf("unknown font %q, expected one of: %s", name, strings.Join(fontNames(), ", "))
+}
+
+// loadFont returns the font called name from the assets, which is the
+// built-in one unless it has been overridden. An override that was
+// removed since validateFont is an error like any other.
+func loadFont(name string) (*font, error) {
+	asset := fontAsset(name)
+	data, err := readAsset(asset)
+	if err != nil {
+		return nil, fmt.Errorf("could not load font %s: %w", name, err)
+	}
+	fnt, err := parseFont(bytes.NewReader(data))
+	if err != nil {
+		where := assetPath(asset)
+		if where == "" {
+			where = "assets/" + asset
+		}
+		return nil, fmt.Errorf("font %s: %v", where, err)
+	}
+	return fnt, nil
+}
+
+// parseFont reads a font file: a "height N" line, then for each glyph a
+// line holding a colon and the character followed by N rows ending in @.
+// Lines starting with # are comments.
+func parseFont(r io.Reader) (*font, error) {
+	f := &font{glyphs: map[rune][]string{}}
+	scanner := bufio.NewScanner(r)
+	var cur rune
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		switch {
+		case f.height == 0:
+			if strings.HasPrefix(line, "#") || line == "" {
+				continue
+			}
+			h, err := strconv.Atoi(strings.TrimPrefix(line, "height "))
+			if err != nil || h <= 0 {
+				return nil, fmt.Errorf("line %d: expected height", n)
+			}
+			f.height = h
+		case strings.HasPrefix(line, ":"):
+			ch, size := utf8.DecodeRuneInString(line[1:])
+			if size == 0 || len(line) != 1+size {
+				return nil, fmt.Errorf("line %d: expected a single character", n)
+			}
+			cur = ch
+			f.glyphs[cur] = nil
+		case strings.HasSuffix(line, "@"):
+			rows, ok := f.glyphs[cur]
+			if !ok || len(rows) == f.height {
+				return nil, fmt.Errorf("line %d: row outside of a glyph", n)
+			}
+			f.glyphs[cur] = append(rows, strings.TrimSuffix(line, "@"))
+		default:
+			return nil, fmt.Errorf("line %d: row must end with @", n)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for ch, rows := range f.glyphs {
+		if len(rows) != f.height {
+			return nil, fmt.Errorf("glyph %q has %d rows, expected %d", ch, len(rows), f.height)
+		}
+	}
+	return f, nil
+}
+
+// render draws s in the font, one string per row. Letters are drawn in
+// upper case. It returns false if the font has no glyph for some
+// character of s.
+func (f *font) render(s string) ([]string, bool) {
+	rows := make([]string, f.height)
+	for i, ch := range s {
+		g, ok := f.glyphs[unicode.ToUpper(ch)]
+		if !ok {
+			return nil, false
+		}
+		for j := range rows {
+			if i > 0 {
+				rows[j] += " "
+			}
+			rows[j] += g[j]
+		}
+	}
+	return rows, true
+}
+
+// bannerEncoder writes every greeting in the large letters of fnt, with a
+// blank line after it, or as it is if fnt has no glyph for one of its
+// characters. Rows are colored with the SGR parameters sgr if set.
+type bannerEncoder struct {
+	fnt *font
+	sgr string
+	// the last greeting and what it came out as, most runs greet with
+	// the same line over and over
+	last     string
+	rendered []byte
+}
+
+func (b *bannerEncoder) appendLine(dst []byte, name, greeting string, index int) []byte {
+	if greeting != b.last || b.rendered == nil {
+		b.last, b.rendered = greeting, b.render(greeting)
+	}
+	return append(dst, b.rendered...)
+}
+
+func (b *bannerEncoder) render(greeting string) []byte {
+	rows, ok := b.fnt.render(greeting)
+	if !ok {
+		rows = []string{greeting}
+	}
+	var out []byte
+	for _, row := range rows {
+		if b.sgr != "" {
+			row = paint(b.sgr, row)
+		}
+		out = append(out, row...)
+		out = append(out, '\n')
+	}
+	return append(out, '\n')
+}
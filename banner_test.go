@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// blockFont is the built-in default font, without any override, for the
+// tests that draw with it.
+var blockFont = func() *font {
+	data, err := builtinAssets.ReadFile("assets/" + fontAsset(defaultFont))
+	if err != nil {
+		panic(err)
+	}
+	fnt, err := parseFont(bytes.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+	return fnt
+}()
+
+func TestLoadFontMissing(t *testing.T) {
+	// an override removed after --font was checked
+	if _, err := loadFont("gone"); err == nil || !strings.HasPrefix(err.Error(), "could not load font gone: ") {
+		t.Errorf("expected an error loading a missing font, got: %v\n", err)
+	}
+}
+
+func TestFontRender(t *testing.T) {
+	rows, ok := blockFont.render("Hi!")
+	if !ok {
+		t.Fatal("expected Hi! to render")
+	}
+	expected := []string{
+		"#   # ### #",
+		"#   #  #  #",
+		"#####  #  #",
+		"#   #  #   ",
+		"#   # ### #",
+	}
+	if strings.Join(rows, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s\n", strings.Join(expected, "\n"), strings.Join(rows, "\n"))
+	}
+
+	if _, ok := blockFont.render("Zoë"); ok {
+		t.Error("expected no glyph for ë")
+	}
+}
+
+func TestParseFont(t *testing.T) {
+	tests := []struct {
+		src string
+		err error
+	}{
+		{src: "# comment\nheight 2\n:a\n#@\n#@\n", err: nil},
+		{src: ":a\n#@\n", err: errors.New("line 1: expected height")},
+		{src: "height 2\n:ab\n", err: errors.New("line 2: expected a single character")},
+		{src: "height 2\n#@\n", err: errors.New("line 2: row outside of a glyph")},
+		{src: "height 2\n:a\n#\n", err: errors.New("line 3: row must end with @")},
+		{src: "height 2\n:a\n#@\n", err: errors.New(`glyph 'a' has 1 rows, expected 2`)},
+	}
+
+	for _, tc := range tests {
+		_, err := parseFont(strings.NewReader(tc.src))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Errorf("expected error to be: %v, got: %v\n", tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Errorf("expected nil error, got: %v\n", err)
+		}
+	}
+}
+
+func TestBannerGolden(t *testing.T) {
+	for _, name := range []string{"block", "mini"} {
+		expected, err := os.ReadFile(filepath.Join("testdata", "banner-"+name+".golden"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		c := config{numTimes: 1, banner: true, font: name}
+		if err := greetUser(context.Background(), c, "Benny", out); err != nil {
+			t.Fatalf("%s: expected nil error, got: %v\n", name, err)
+		}
+		if out.String() != string(expected) {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s\n", name, expected, out.String())
+		}
+	}
+}
+
+func TestBanner(t *testing.T) {
+	// a name the font can't draw is greeted as it is
+	out := new(bytes.Buffer)
+	if err := greetUser(context.Background(), config{numTimes: 2, banner: true, font: "mini"}, "Zoë", out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Nice to meet you Zoë\n\nNice to meet you Zoë\n\n" {
+		t.Errorf("expected the plain greeting, got: %q\n", out.String())
+	}
+
+	// every row is colored
+	out.Reset()
+	if err := greetUser(context.Background(), config{numTimes: 1, banner: true, font: "mini", color: colorAlways, template: "Hi"}, "Benny", out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "\x1b[1;32m    ___\x1b[0m\n\x1b[1;32m|_|  | \x1b[0m\n\x1b[1;32m| | _|_\x1b[0m\n\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got: %q\n", expected, out.String())
+	}
+
+	tests := []struct {
+		c   config
+		err error
+	}{
+		{c: config{numTimes: 1, banner: true, font: "mini"}},
+		{c: config{numTimes: 1, banner: true, font: "comic"}, err: errors.New(`unknown font "comic", expected one of: block, mini`)},
+		{c: config{numTimes: 1, banner: true, font: "block", output: outputJSON}, err: errors.New("--banner cannot be used with --output json")},
+	}
+	for _, tc := range tests {
+		err := validateArgs(withDefaults(tc.c))
+		if tc.err == nil && err != nil {
+			t.Errorf("%+v: expected nil error, got: %v\n", tc.c, err)
+		}
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Errorf("%+v: expected error: %v, got: %v\n", tc.c, tc.err, err)
+		}
+	}
+}
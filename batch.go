@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// runBatch greets every name read from r, one per line, without
+// prompting. Blank lines are skipped. A name that can't be greeted (say
+// it is on the denylist) is reported with its line number and the batch
+// carries on, the returned error then says how many names were skipped.
+// Names on the suppression list are skipped without a message and don't
+// count as failures.
+// It returns the number of names greeted.
+func runBatch(ctx context.Context, r io.Reader, w io.Writer, c config) (int, error) {
+	if c.explain {
+		explain(w, c)
+	}
+
+	scanner := bufio.NewScanner(r)
+	greeted, failed, line := 0, 0, 0
+	var first error
+	for scanner.Scan() {
+		line++
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			continue
+		}
+
+		lc := c
+		lc.name = name
+		_, err := greetVisitor(ctx, scanner, w, lc)
+		if ctx.Err() != nil {
+			return greeted, errInterrupted
+		}
+		if err == errSuppressed {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(w, "line %d: %s\n", line, c.messages().errorText(err))
+			if failed == 0 {
+				first = fmt.Errorf("line %d: %w", line, err)
+			}
+			failed++
+			continue
+		}
+		greeted++
+	}
+	if err := scanner.Err(); err != nil {
+		return greeted, fmt.Errorf("line %d: %w", line+1, err)
+	}
+
+	if failed > 0 {
+		return greeted, batchError{greeted: greeted, failed: failed, first: first}
+	}
+	return greeted, nil
+}
+
+// batchError is what a batch in which some names couldn't be greeted
+// fails with. The greetings of the others were written and recorded, so
+// the output file keeps them, see outputFile.finish. It wraps the error
+// of the first name that failed, which decides the exit code.
+type batchError struct {
+	greeted, failed int
+	first           error
+}
+
+func (e batchError) Error() string {
+	return fmt.Sprintf("%d of %d names could not be greeted", e.failed, e.greeted+e.failed)
+}
+
+func (e batchError) Unwrap() error { return e.first }
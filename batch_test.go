@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunBatch(t *testing.T) {
+	tests := []struct {
+		c       config
+		input   string
+		output  string
+		greeted int
+		err     error
+	}{
+		{
+			c:       config{numTimes: 2, stdinBatch: true},
+			input:   "Benny\nAda\n",
+			output:  strings.Repeat("Nice to meet you Benny\n", 2) + strings.Repeat("Nice to meet you Ada\n", 2),
+			greeted: 2,
+		},
+		{
+			// blank lines, surrounding whitespace, CRLF line endings and a
+			// last line without a newline
+			c:       config{numTimes: 1, stdinBatch: true},
+			input:   "\n  Benny  \r\n\n\t\nAda",
+			output:  "Nice to meet you Benny\nNice to meet you Ada\n",
+			greeted: 2,
+		},
+		{
+			c:       config{numTimes: 1, stdinBatch: true},
+			input:   "",
+			output:  "",
+			greeted: 0,
+		},
+		{
+			c:       config{numTimes: 1, stdinBatch: true, filterMode: filterReject},
+			input:   "Benny\nShit Head\nAda\n",
+			output:  "Nice to meet you Benny\nline 2: that name is not allowed\nNice to meet you Ada\n",
+			greeted: 2,
+			err:     errors.New("1 of 3 names could not be greeted"),
+		},
+	}
+
+	byteBuf := new(bytes.Buffer)
+	for _, tc := range tests {
+		greeted, err := runBatch(context.Background(), strings.NewReader(tc.input), byteBuf, tc.c)
+		if tc.err == nil && err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("expected error: %v, got error: %v\n", tc.err, err)
+		}
+		if greeted != tc.greeted {
+			t.Errorf("expected %v names to be greeted, got: %v\n", tc.greeted, greeted)
+		}
+		if got := byteBuf.String(); got != tc.output {
+			t.Errorf("expected stdout message to be: %q, got: %q\n", tc.output, got)
+		}
+		byteBuf.Reset()
+	}
+}
+
+func TestRunBatchLongLine(t *testing.T) {
+	input := "Benny\n" + strings.Repeat("x", 70*1024) + "\n"
+	greeted, err := runBatch(context.Background(), strings.NewReader(input), new(bytes.Buffer), config{numTimes: 1, stdinBatch: true})
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("expected an error for line 2, got: %v\n", err)
+	}
+	if greeted != 1 {
+		t.Errorf("expected the names before the long line to be greeted, got: %v\n", greeted)
+	}
+}
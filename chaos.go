@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The chaos flags break the output on purpose, so scripts wrapping the
+// tool can test how they cope with a failing or garbled run. They are
+// left out of the usage text and completions, see hiddenFlags.
+
+// corruptEvery is how often --corrupt-output garbles a byte.
+const corruptEvery = 10
+
+// chaosWriter wraps the output with the failures asked for by the chaos
+// flags.
+type chaosWriter struct {
+	w         io.Writer
+	failAfter int // lines, 0 for never
+	slow      time.Duration
+	corrupt   bool
+	lines     int // written so far
+	offset    int // bytes written so far, for corrupt
+}
+
+// chaosOutput returns w wrapped in a chaosWriter if any chaos flag is set,
+// and w itself otherwise.
+func chaosOutput(w io.Writer, c config) io.Writer {
+	if c.failAfter <= 0 && c.slowWrites <= 0 && !c.corrupt {
+		return w
+	}
+	return &chaosWriter{w: w, failAfter: c.failAfter, slow: c.slowWrites, corrupt: c.corrupt}
+}
+
+func (cw *chaosWriter) injectedError() error {
+	return ioError{fmt.Errorf("injected failure after %d lines of output", cw.failAfter)}
+}
+
+// Write sleeps for --slow-writes, writes at most up to the --fail-after th
+// line and fails from then on, garbling every corruptEvery th byte other
+// than newlines with --corrupt-output.
+func (cw *chaosWriter) Write(p []byte) (int, error) {
+	if cw.slow > 0 {
+		time.Sleep(cw.slow)
+	}
+
+	var err error
+	if cw.failAfter > 0 {
+		left := cw.failAfter - cw.lines
+		if left <= 0 {
+			return 0, cw.injectedError()
+		}
+		if end := nthLineEnd(p, left); end >= 0 && end < len(p) {
+			p, err = p[:end], cw.injectedError()
+		}
+	}
+
+	out := p
+	if cw.corrupt {
+		out = make([]byte, len(p))
+		for i, b := range p {
+			if b != '\n' && (cw.offset+i)%corruptEvery == corruptEvery-1 {
+				b = 0xff
+			}
+			out[i] = b
+		}
+	}
+
+	n, werr := cw.w.Write(out)
+	cw.lines += bytes.Count(out[:n], []byte{'\n'})
+	cw.offset += n
+	if werr != nil {
+		return n, werr
+	}
+	return n, err
+}
+
+// nthLineEnd returns the index just past the nth newline in p, or -1 if p
+// has fewer lines.
+func nthLineEnd(p []byte, n int) int {
+	end := 0
+	for ; n > 0; n-- {
+		i := bytes.IndexByte(p[end:], '\n')
+		if i < 0 {
+			return -1
+		}
+		end += i + 1
+	}
+	return end
+}
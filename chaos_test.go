@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChaosWriter(t *testing.T) {
+	tests := []struct {
+		c      config
+		writes []string
+		output string
+		err    string
+	}{
+		{
+			c:      config{failAfter: 2},
+			writes: []string{"one\ntwo\nthree\n"},
+			output: "one\ntwo\n",
+			err:    "injected failure after 2 lines of output",
+		},
+		{
+			c:      config{failAfter: 2},
+			writes: []string{"one\n", "two\n", "three\n"},
+			output: "one\ntwo\n",
+			err:    "injected failure after 2 lines of output",
+		},
+		{
+			c:      config{failAfter: 3},
+			writes: []string{"one\ntwo\nthree\n"},
+			output: "one\ntwo\nthree\n",
+		},
+		{
+			c:      config{corrupt: true},
+			writes: []string{"Nice to me", "et you Benny\n"},
+			output: "Nice to m\xffet you Be\xffny\n",
+		},
+	}
+
+	for _, tc := range tests {
+		out := new(bytes.Buffer)
+		w := chaosOutput(out, tc.c)
+		var err error
+		for _, s := range tc.writes {
+			if _, err = w.Write([]byte(s)); err != nil {
+				break
+			}
+		}
+		if tc.err == "" && err != nil {
+			t.Errorf("expected nil error, got: %v\n", err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("expected error: %v, got: %v\n", tc.err, err)
+		}
+		if out.String() != tc.output {
+			t.Errorf("expected output to be: %q, got: %q\n", tc.output, out.String())
+		}
+	}
+}
+
+func TestChaosOutputOff(t *testing.T) {
+	out := new(bytes.Buffer)
+	if w := chaosOutput(out, config{}); w != out {
+		t.Errorf("expected the output as is without chaos flags, got: %T\n", w)
+	}
+}
+
+func TestChaosSlowWrites(t *testing.T) {
+	w := chaosOutput(new(bytes.Buffer), config{slowWrites: 20 * time.Millisecond})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		w.Write([]byte("Nice to meet you Benny\n"))
+	}
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("expected three writes to take at least 60ms, took: %v\n", d)
+	}
+}
+
+func TestChaosFlagsHidden(t *testing.T) {
+	completion := bashCompletion("name-cli")
+	for name := range hiddenFlags {
+		if strings.Contains(completion, "--"+name) {
+			t.Errorf("expected completion not to offer --%v\n", name)
+		}
+	}
+}
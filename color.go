@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// --color settings
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+const defaultTheme = "default"
+
+// theme is how the prompt and the greeting are colored, as the parameters
+// of an ANSI SGR sequence, "" for none.
+type theme struct {
+	prompt   string
+	greeting string
+}
+
+var themes = map[string]theme{
+	defaultTheme: {prompt: "36", greeting: "1;32"},
+	"bright":     {prompt: "96", greeting: "1;92"},
+	"monochrome": {prompt: "2", greeting: "1"},
+}
+
+func themeNames() []string {
+	names := []string{}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateColor(c config) error {
+	switch c.color {
+	case "", colorAuto, colorAlways, colorNever:
+	default:
+		return fmt.Errorf("unknown --color %q, expected %s, %s or %s", c.color, colorAuto, colorAlways, colorNever)
+	}
+	if _, ok := themes[c.theme]; c.theme != "" && !ok {
+		return fmt.Errorf("unknown theme %q, expected one of: %s", c.theme, strings.Join(themeNames(), ", "))
+	}
+	return nil
+}
+
+// colorTheme returns the theme to color output to w with, and false if it
+// shouldn't be colored: with --color never, and with --color auto if w
+// isn't a terminal that understands ANSI colors or NO_COLOR is set, see
+// https://no-color.org.
+func (c config) colorTheme(w io.Writer) (theme, bool) {
+	switch c.color {
+	case colorNever:
+		return theme{}, false
+	case colorAlways:
+	default:
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !isTerminal(w) {
+			return theme{}, false
+		}
+		if !enableColor(w.(*os.File)) {
+			return theme{}, false
+		}
+	}
+	if t, ok := themes[c.theme]; ok {
+		return t, true
+	}
+	return themes[defaultTheme], true
+}
+
+// paint wraps s in the SGR sequence sgr and a reset.
+func paint(sgr, s string) string {
+	if sgr == "" {
+		return s
+	}
+	return "\x1b[" + sgr + "m" + s + "\x1b[0m"
+}
+
+// appendLine is textEncoder.appendLine in the greeting color of t.
+func (t theme) appendLine(dst []byte, name, greeting string, index int) []byte {
+	if t.greeting == "" {
+		return textEncoder{}.appendLine(dst, name, greeting, index)
+	}
+	dst = append(dst, "\x1b["...)
+	dst = append(dst, t.greeting...)
+	dst = append(dst, 'm')
+	dst = append(dst, greeting...)
+	return append(dst, "\x1b[0m\n"...)
+}
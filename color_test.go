@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestColorTheme(t *testing.T) {
+	tests := []struct {
+		c     config
+		noEnv bool
+		theme theme
+		ok    bool
+	}{
+		// a buffer isn't a terminal
+		{c: config{color: colorAuto}},
+		{c: config{color: colorNever}},
+		{c: config{color: colorAlways}, theme: themes[defaultTheme], ok: true},
+		{c: config{color: colorAlways, theme: "bright"}, theme: themes["bright"], ok: true},
+		// asking for color wins over $NO_COLOR
+		{c: config{color: colorAlways, theme: "monochrome"}, noEnv: true, theme: themes["monochrome"], ok: true},
+		{c: config{color: colorAuto}, noEnv: true},
+	}
+
+	for _, tc := range tests {
+		if tc.noEnv {
+			t.Setenv("NO_COLOR", "1")
+		}
+		th, ok := tc.c.colorTheme(new(bytes.Buffer))
+		if th != tc.theme || ok != tc.ok {
+			t.Errorf("%+v: expected %+v, %v, got: %+v, %v\n", tc.c, tc.theme, tc.ok, th, ok)
+		}
+	}
+
+	// /dev/null passes for a terminal, $NO_COLOR still turns color off
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer null.Close()
+	t.Setenv("NO_COLOR", "1")
+	if _, ok := (config{color: colorAuto}).colorTheme(null); ok {
+		t.Error("expected no color with $NO_COLOR set")
+	}
+}
+
+func TestColorOutput(t *testing.T) {
+	out := new(bytes.Buffer)
+	c := withDefaults(config{numTimes: 2, color: colorAlways, theme: "bright"})
+	if err := runCmd(context.Background(), strings.NewReader("Benny\n"), out, c); err != nil {
+		t.Fatal(err)
+	}
+	expected := "\x1b[96mYour name please? Press the return key when done.\x1b[0m\n" + strings.Repeat("\x1b[1;92mNice to meet you Benny\x1b[0m\n", 2)
+	if out.String() != expected {
+		t.Errorf("expected %q, got: %q\n", expected, out.String())
+	}
+
+	// machine readable output stays plain
+	out.Reset()
+	c = withDefaults(config{numTimes: 1, name: "Benny", color: colorAlways, output: outputJSON})
+	if err := runCmd(context.Background(), strings.NewReader(""), out, c); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Errorf("expected no color in JSON output, got: %q\n", out.String())
+	}
+}
+
+func TestValidateColor(t *testing.T) {
+	tests := []struct {
+		c   config
+		err error
+	}{
+		{c: config{color: colorAuto, theme: defaultTheme}},
+		{c: config{color: "sometimes"}, err: errors.New(`unknown --color "sometimes", expected auto, always or never`)},
+		{c: config{theme: "neon"}, err: errors.New(`unknown theme "neon", expected one of: bright, default, monochrome`)},
+	}
+
+	for _, tc := range tests {
+		err := validateColor(tc.c)
+		if tc.err == nil && err != nil {
+			t.Errorf("%+v: expected nil error, got: %v\n", tc.c, err)
+		}
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Errorf("%+v: expected error: %v, got: %v\n", tc.c, tc.err, err)
+		}
+	}
+}
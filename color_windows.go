@@ -0,0 +1,29 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// enableVirtualTerminalProcessing makes the console interpret ANSI escape
+// sequences, which it does since Windows 10.
+const enableVirtualTerminalProcessing = 0x0004
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// enableColor switches the console f writes to over to ANSI escape
+// sequences, and reports false if it can't, as on older Windows.
+func enableColor(f *os.File) bool {
+	h := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	ok, _, _ := procSetConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+	return ok != 0
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// command is a subcommand of the tool, as listed in the usage text and
+// the completion scripts.
+type command struct {
+	name    string
+	args    string // what follows the name in the usage line
+	summary string
+	// flags returns the FlagSet of the command bound to c, nil for commands
+	// without options
+	flags func(c *config) *flag.FlagSet
+	// defaults is the config the command starts parsing from, defaultConfig
+	// if nil
+	defaults func() config
+	// feature the command belongs to, see features, "" if it's always
+	// there
+	feature string
+}
+
+// allCommands in the order they are listed, greet runs when none is given.
+var allCommands = []command{
+	{name: "greet", args: "[options] <count>", summary: "greet a name a number of times, the default command", flags: newFlagSet},
+	{name: "serve", args: "[--addr <host:port>] [--max-times <count>]", summary: "answer greetings over HTTP", flags: newServeFlagSet, defaults: serveDefaults, feature: "serve"},
+	{name: "loadtest", args: "[--server <url>] [--rps <count>] [--duration <duration>]", summary: "send synthetic requests to serve and report latency and errors", flags: newLoadtestFlagSet, defaults: loadtestDefaults, feature: "serve"},
+	{name: "display", args: "[--hold <duration>] [--printer <device>] [filter options]", summary: "greet visitors full screen, for a reception desk", flags: newDisplayFlagSet, defaults: displayDefaults},
+	{name: "badge", args: "--name <name> [--contact <url>] [--out <file.pdf>]", summary: "write a printable name badge as PDF", flags: newBadgeFlagSet, defaults: badgeDefaults, feature: "badge"},
+	{name: "lint", args: "<file>", summary: "check a file of names for --stdin-batch for empty lines, odd characters and duplicates", flags: newHelpFlagSet},
+	{name: "diff", args: "<run-a.json> <run-b.json>", summary: "compare two --report files, or the greetings of two runs with --output json", flags: newHelpFlagSet},
+	{name: "history", args: "[--name <name>] [--last <count>]", summary: "list past greetings, kept in the data directory state path shows unless --no-history is given", flags: newHistoryFlagSet},
+	{name: "stats", args: "streaks", summary: "list how many days in a row each name was greeted, from the history", flags: newHelpFlagSet},
+	{name: "state", args: "path | size | clean [--yes]", summary: "show where the config and history are kept and how much space they take, or delete the history", flags: newStateFlagSet},
+	{name: "examples", args: "[topic]", summary: "show examples of how to use the tool"},
+	{name: "version", args: "[--short]", summary: "print the version, also as --version", flags: newVersionFlagSet},
+	{name: "assets", args: "list | export [--force] [dir]", summary: "list the built-in fonts and word lists or export them to customize", flags: newAssetsFlagSet},
+	{name: "service", args: "launchd [--at <HH:MM>] [--load] [-- args]", summary: "write a launchd job that keeps serve running or greets on a schedule", flags: newServiceFlagSet, defaults: serviceDefaults},
+	{name: "features", summary: "list the optional features and whether this build has them", flags: newHelpFlagSet},
+	{name: "completion", args: "<shell>", summary: "print a completion script for bash, zsh, fish or powershell", flags: newHelpFlagSet},
+	{name: "internal", args: "completions-dir <shell>", summary: "print where a package manager should install the completion script", flags: newHelpFlagSet},
+}
+
+// commands are those of allCommands this build has.
+var commands = compiledCommands(allCommands)
+
+func (cmd command) defaultConfig() config {
+	if cmd.defaults == nil {
+		return defaultConfig()
+	}
+	return cmd.defaults()
+}
+
+func findCommand(name string) (command, bool) {
+	return findCommandIn(commands, name)
+}
+
+func findCommandIn(cmds []command, name string) (command, bool) {
+	for _, cmd := range cmds {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// parseArgs picks the command from the first argument and parses the rest
+// with it. Anything that isn't a command name is left to greet, so
+// "name-cli 3" keeps working.
+func parseArgs(args []string) (config, error) {
+	name := ""
+	if len(args) > 0 {
+		if _, ok := findCommand(args[0]); ok {
+			name, args = args[0], args[1:]
+		} else if cmd, ok := findCommandIn(allCommands, args[0]); ok {
+			return config{}, errNotCompiled(cmd)
+		}
+	}
+
+	// --version is what package managers try first
+	if name == "" && len(args) > 0 && (args[0] == "--version" || args[0] == "-version") {
+		return parseVersionArgs(args[1:])
+	}
+
+	switch name {
+	case "serve":
+		return parseServeArgs(args)
+	case "loadtest":
+		return parseLoadtestArgs(args)
+	case "display":
+		return parseDisplayArgs(args)
+	case "badge":
+		return parseBadgeArgs(args)
+	case "lint":
+		return parseLintArgs(args)
+	case "diff":
+		return parseDiffArgs(args)
+	case "history":
+		return parseHistoryArgs(args)
+	case "stats":
+		return parseStatsArgs(args)
+	case "state":
+		return parseStateArgs(args)
+	case "examples":
+		return parseExamplesArgs(args)
+	case "version":
+		return parseVersionArgs(args)
+	case "features":
+		return parseFeaturesArgs(args)
+	case "assets":
+		return parseAssetsArgs(args)
+	case "service":
+		return parseServiceArgs(args)
+	case "completion":
+		return parseCompletionArgs(args)
+	case "internal":
+		return parseInternalArgs(args)
+	}
+	return parseGreetArgs(args)
+}
+
+// runCommand runs the command c was parsed for and returns how many
+// visitors were greeted.
+func runCommand(ctx context.Context, r io.Reader, w io.Writer, c config) (int, error) {
+	if c.printUsage {
+		return 0, runCmd(ctx, r, w, c)
+	}
+	switch c.command {
+	case "serve":
+		return 0, runServe(ctx, w, c)
+	case "loadtest":
+		return 0, runLoadtest(ctx, w, c)
+	case "display":
+		return runDisplay(ctx, r, w, c)
+	case "badge":
+		return 0, runBadge(w, c)
+	case "lint":
+		return 0, runLint(w, c)
+	case "diff":
+		return 0, runDiff(w, c)
+	case "history":
+		return 0, runHistory(w, c)
+	case "stats":
+		return 0, runStats(w, c)
+	case "state":
+		return 0, runState(r, w, c)
+	case "version":
+		return 0, printVersion(w, c.short)
+	case "features":
+		return 0, printFeatures(w)
+	case "assets":
+		return 0, runAssets(w, c)
+	case "service":
+		return 0, runService(w, c)
+	case "completion":
+		return 0, printCompletion(w, filepath.Base(os.Args[0]), c.shell)
+	case "internal":
+		return 0, printCompletionsDir(w, c.shell)
+	}
+	// piped names don't need asking for, and the prompt would end up
+	// mixed in with the greetings
+	if !readsTerminal(r) {
+		c.noPrompt = !c.forcePrompt
+		r = decodeInput(r, c.inputEncoding)
+	}
+	switch {
+	case c.session:
+		return runSession(ctx, r, w, c)
+	case c.stdinBatch:
+		return runBatch(ctx, r, w, c)
+	}
+	// with nobody at the terminal, a missing name isn't worth failing over
+	c.nameFallback = !readsTerminal(r)
+	return 1, runCmd(ctx, r, w, c)
+}
+
+// newHelpFlagSet is the FlagSet of commands that only have --help.
+func newHelpFlagSet(c *config) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&c.printUsage, "help", c.printUsage, "show this help and exit")
+	fs.BoolVar(&c.printUsage, "h", c.printUsage, "show this help and exit")
+	return fs
+}
+
+// parseHelpArgs parses the arguments of a command using newHelpFlagSet,
+// returning the positional arguments.
+func parseHelpArgs(c *config, args []string) ([]string, error) {
+	fs := newHelpFlagSet(c)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return fs.Args(), nil
+}
+
+func parseExamplesArgs(args []string) (config, error) {
+	c := config{command: "examples", printExamples: true}
+	if len(args) > 1 {
+		return config{}, ErrInvalidArgCount
+	}
+	if len(args) == 1 {
+		c.exampleTopic = args[0]
+	}
+	return c, nil
+}
+
+func newVersionFlagSet(c *config) *flag.FlagSet {
+	fs := newHelpFlagSet(c)
+	fs.BoolVar(&c.short, "short", c.short, "print only the version number, for scripts")
+	return fs
+}
+
+func parseVersionArgs(args []string) (config, error) {
+	c := config{command: "version"}
+	fs := newVersionFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if fs.NArg() != 0 && !c.printUsage {
+		return config{}, ErrInvalidArgCount
+	}
+	return c, nil
+}
+
+func parseCompletionArgs(args []string) (config, error) {
+	c := config{command: "completion"}
+	rest, err := parseHelpArgs(&c, args)
+	if err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return c, nil
+	}
+	if len(rest) != 1 {
+		return config{}, ErrInvalidArgCount
+	}
+	c.shell = rest[0]
+	if err := validateShell(c.shell); err != nil {
+		return config{}, err
+	}
+	return c, nil
+}
+
+// parseInternalArgs parses "internal completions-dir <shell>", the one
+// action so far.
+func parseInternalArgs(args []string) (config, error) {
+	c := config{command: "internal"}
+	rest, err := parseHelpArgs(&c, args)
+	if err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return c, nil
+	}
+	if len(rest) == 0 {
+		return config{}, errors.New("expected completions-dir")
+	}
+	if rest[0] != "completions-dir" {
+		return config{}, fmt.Errorf("unknown action %q, expected completions-dir", rest[0])
+	}
+	cc, err := parseCompletionArgs(rest[1:])
+	if err != nil {
+		return config{}, err
+	}
+	c.shell, c.printUsage = cc.shell, cc.printUsage
+	return c, nil
+}
+
+// printCommandUsage writes the help of a single command.
+func printCommandUsage(w io.Writer, prog, name string, m messages) error {
+	cmd, ok := findCommand(name)
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	if name == "greet" {
+		fmt.Fprint(w, renderUsage(prog, usageText, m))
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n\n%s.\n", m.usage, strings.TrimSpace(prog+" "+cmd.name+" "+cmd.args), capitalize(cmd.summary))
+	if cmd.flags != nil {
+		c := cmd.defaultConfig()
+		fmt.Fprintf(&b, "\n%s:\n", m.options)
+		writeUsageFlags(&b, usageFlags(cmd.flags(&c)))
+	}
+	fmt.Fprint(w, b.String())
+	return nil
+}
+
+// version is set when building releases, with
+// -ldflags "-X main.version=1.2.3".
+var version = ""
+
+// buildVersion falls back to the module version go install recorded, and
+// to "dev" for builds from a checkout.
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// printVersion writes "name-cli 1.2.3", or with short just "1.2.3", also
+// for versions go install recorded as v1.2.3, so scripts can compare it
+// with the release they installed.
+func printVersion(w io.Writer, short bool) error {
+	if short {
+		_, err := fmt.Fprintln(w, strings.TrimPrefix(buildVersion(), "v"))
+		return err
+	}
+	_, err := fmt.Fprintf(w, "name-cli %s\n", buildVersion())
+	return err
+}
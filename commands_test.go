@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgsCommands(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+		c    config
+	}{
+		{
+			args: []string{"version"},
+			c:    config{command: "version"},
+		},
+		{
+			args: []string{"version", "extra"},
+			err:  ErrInvalidArgCount,
+		},
+		{
+			args: []string{"version", "--short"},
+			c:    config{command: "version", short: true},
+		},
+		{
+			args: []string{"--version"},
+			c:    config{command: "version"},
+		},
+		{
+			args: []string{"--version", "--short"},
+			c:    config{command: "version", short: true},
+		},
+		{
+			args: []string{"internal", "completions-dir", "bash"},
+			c:    config{command: "internal", shell: "bash"},
+		},
+		{
+			args: []string{"internal", "completions-dir", "tcsh"},
+			err:  errors.New(`unknown shell "tcsh", expected one of: bash, zsh, fish, powershell`),
+		},
+		{
+			args: []string{"internal"},
+			err:  errors.New("expected completions-dir"),
+		},
+		{
+			args: []string{"internal", "config-dir"},
+			err:  errors.New(`unknown action "config-dir", expected completions-dir`),
+		},
+		{
+			args: []string{"completion", "bash"},
+			c:    config{command: "completion", shell: "bash"},
+		},
+		{
+			args: []string{"completion", "--help"},
+			c:    config{command: "completion", printUsage: true},
+		},
+		{
+			args: []string{"completion", "tcsh"},
+			err:  errors.New(`unknown shell "tcsh", expected one of: bash, zsh, fish, powershell`),
+		},
+		{
+			args: []string{"completion"},
+			err:  ErrInvalidArgCount,
+		},
+		{
+			args: []string{"examples", "kiosk"},
+			c:    config{command: "examples", printExamples: true, exampleTopic: "kiosk"},
+		},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(tc.args)
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("%v: expected error to be: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("%v: expected nil error, got: %v\n", tc.args, err)
+		}
+		if !reflect.DeepEqual(c, tc.c) {
+			t.Errorf("%v: expected config to be: %+v, got: %+v\n", tc.args, tc.c, c)
+		}
+	}
+}
+
+func TestParseArgsGreetCommand(t *testing.T) {
+	implicit, err := parseArgs([]string{"--name", "Benny", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	explicit, err := parseArgs([]string{"greet", "--name", "Benny", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(implicit, explicit) {
+		t.Errorf("expected greet to be the default command, got: %+v and %+v\n", implicit, explicit)
+	}
+}
+
+func TestPrintCommandUsage(t *testing.T) {
+	for _, cmd := range commands {
+		var b bytes.Buffer
+		if err := printCommandUsage(&b, "name-cli", cmd.name, catalogs[defaultLang]); err != nil {
+			t.Fatalf("%s: expected nil error, got: %v\n", cmd.name, err)
+		}
+		if !strings.HasPrefix(b.String(), "Usage: name-cli") {
+			t.Errorf("%s: expected a usage line, got: %q\n", cmd.name, b.String())
+		}
+		for _, f := range commandFlags(cmd) {
+			if len(f) > 2 && !strings.Contains(b.String(), f) {
+				t.Errorf("%s: expected the usage to mention %s\n", cmd.name, f)
+			}
+		}
+	}
+}
+
+func TestPrintVersion(t *testing.T) {
+	var b bytes.Buffer
+	printVersion(&b, false)
+	if !strings.HasPrefix(b.String(), "name-cli ") {
+		t.Errorf("expected the version, got: %q\n", b.String())
+	}
+
+	defer func(v string) { version = v }(version)
+	for _, v := range []string{"1.2.3", "v1.2.3"} {
+		version = v
+		b.Reset()
+		printVersion(&b, true)
+		if b.String() != "1.2.3\n" {
+			t.Errorf("%v: expected just the number, got: %q\n", v, b.String())
+		}
+	}
+
+	out, err := exec.Command("./"+binaryName, "--version", "--short").CombinedOutput()
+	if err != nil || strings.Count(string(out), "\n") != 1 || strings.HasPrefix(string(out), "name-cli") || strings.HasPrefix(string(out), "v") {
+		t.Errorf("expected only the version number, got: %v: %q\n", err, out)
+	}
+}
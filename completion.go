@@ -0,0 +1,370 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// shells completion scripts can be printed for.
+var shells = []string{"bash", "zsh", "fish", "powershell"}
+
+func validateShell(shell string) error {
+	if contains(shells, shell) {
+		return nil
+	}
+	return fmt.Errorf("unknown shell %q, expected one of: %s", shell, strings.Join(shells, ", "))
+}
+
+// printCompletion writes the completion script for shell. The scripts are
+// generated from the commands and their FlagSets, so they keep up as
+// flags are added.
+func printCompletion(w io.Writer, prog, shell string) error {
+	// completing name-cli.exe on Windows
+	prog = strings.TrimSuffix(prog, ".exe")
+	var script string
+	switch shell {
+	case "zsh":
+		script = zshCompletion(prog)
+	case "fish":
+		script = fishCompletion(prog)
+	case "powershell":
+		script = powershellCompletion(prog)
+	default:
+		script = bashCompletion(prog)
+	}
+	_, err := fmt.Fprint(w, script)
+	return err
+}
+
+// completionsDir is where the completion script for shell goes for an
+// install with the binary in <prefix>/bin, like Homebrew's and most Linux
+// packages, e.g. <prefix>/share/bash-completion/completions. Symlinks are
+// followed, so with Homebrew it is the directory in the keg, which brew
+// links into place. PowerShell has no such directory, its scripts are
+// loaded from the profile.
+func completionsDir(exe, shell string) (string, error) {
+	var dir string
+	switch shell {
+	case "bash":
+		dir = filepath.Join("bash-completion", "completions")
+	case "zsh":
+		dir = filepath.Join("zsh", "site-functions")
+	case "fish":
+		dir = filepath.Join("fish", "vendor_completions.d")
+	default:
+		return "", fmt.Errorf("%s has no completions directory, load the output of 'name-cli completion %s' in the profile instead", shell, shell)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	prefix := filepath.Dir(filepath.Dir(exe))
+	return filepath.Join(prefix, "share", dir), nil
+}
+
+func printCompletionsDir(w io.Writer, shell string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find the path of name-cli: %w", err)
+	}
+	dir, err := completionsDir(exe, shell)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, dir)
+	return err
+}
+
+// completionFlag is a flag of a command as the completion scripts offer
+// it.
+type completionFlag struct {
+	name  string // the long name, without dashes
+	short string // its alias in flagAliases, if any
+	usage string
+	// arg names the value the flag takes, "" if it takes none
+	arg string
+}
+
+// files reports whether the value of f is a path, to complete file names
+// for.
+func (f completionFlag) files() bool {
+	return strings.HasPrefix(f.arg, "file") || f.arg == "path"
+}
+
+// choices are the values of a flag with an argument like text|json, nil
+// if it takes anything.
+func (f completionFlag) choices() []string {
+	if !strings.Contains(f.arg, "|") || strings.ContainsAny(f.arg, ":/ ") {
+		return nil
+	}
+	return strings.Split(f.arg, "|")
+}
+
+// completionFlags lists the flags of a command, sorted by name, with the
+// aliases folded into the flags they stand for.
+func completionFlags(cmd command) []completionFlag {
+	if cmd.flags == nil {
+		return nil
+	}
+	shorts := map[string]string{}
+	for short, long := range flagAliases {
+		shorts[long] = short
+	}
+	c := cmd.defaultConfig()
+	fs := cmd.flags(&c)
+	flags := []completionFlag{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if hiddenFlags[f.Name] {
+			return
+		}
+		// an alias the command doesn't register under its long name
+		if long, ok := flagAliases[f.Name]; ok && fs.Lookup(long) != nil {
+			return
+		}
+		cf := completionFlag{name: f.Name, usage: f.Usage}
+		if fs.Lookup(shorts[f.Name]) != nil {
+			cf.short = shorts[f.Name]
+		}
+		cf.arg, cf.usage = flag.UnquoteUsage(f)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			cf.arg = ""
+		}
+		flags = append(flags, cf)
+	})
+	return flags
+}
+
+// commandFlags lists the flags of a command as typed on the command line,
+// e.g. "-h" and "--help".
+func commandFlags(cmd command) []string {
+	flags := []string{}
+	for _, f := range completionFlags(cmd) {
+		flags = append(flags, dashes(f.name))
+		if f.short != "" {
+			flags = append(flags, dashes(f.short))
+		}
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// dashes is the flag name as typed, -n or --times.
+func dashes(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// takesFiles reports whether the arguments of cmd are files, like the
+// <file> of lint.
+func takesFiles(cmd command) bool {
+	return strings.Contains(cmd.args, "<file") || strings.Contains(cmd.args, ".json>")
+}
+
+// completionFunc is the name of the shell function completing prog.
+func completionFunc(prog string) string {
+	return "_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, prog)
+}
+
+func bashCompletion(prog string) string {
+	fn := completionFunc(prog)
+	names := []string{}
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	greet, _ := findCommand("greet")
+	greetFlags := strings.Join(commandFlags(greet), " ")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s, generated by '%s completion bash'\n", prog, prog)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur=${COMP_WORDS[COMP_CWORD]} words\n")
+	b.WriteString("    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "        words=\"%s %s\"\n", strings.Join(names, " "), greetFlags)
+	b.WriteString("    else\n        case ${COMP_WORDS[1]} in\n")
+	for _, cmd := range commands {
+		if cmd.name == "greet" {
+			continue
+		}
+		fmt.Fprintf(&b, "        %s) words=\"%s\" ;;\n", cmd.name, strings.Join(commandFlags(cmd), " "))
+	}
+	fmt.Fprintf(&b, "        *) words=\"%s\" ;;\n", greetFlags)
+	b.WriteString("        esac\n    fi\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n}\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, prog)
+	return b.String()
+}
+
+// zshCompletion is installed as _<prog> in $fpath, or loaded with
+// source <(name-cli completion zsh).
+func zshCompletion(prog string) string {
+	fn := completionFunc(prog)
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n", prog)
+	fmt.Fprintf(&b, "# zsh completion for %s, generated by '%s completion zsh'\n", prog, prog)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local -a commands greet_flags\n")
+	b.WriteString("    commands=(\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "        %s\n", shellQuote(cmd.name+":"+cmd.summary))
+	}
+	b.WriteString("    )\n")
+	greet, _ := findCommand("greet")
+	b.WriteString("    greet_flags=(\n")
+	for _, spec := range zshFlagSpecs(greet) {
+		fmt.Fprintf(&b, "        %s\n", spec)
+	}
+	b.WriteString("    )\n")
+	b.WriteString("    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then\n")
+	b.WriteString("        _describe command commands\n        return\n    fi\n")
+	b.WriteString("    case $words[2] in\n")
+	for _, cmd := range commands {
+		b.WriteString("    " + cmd.name + ")\n")
+		b.WriteString("        shift words\n        (( CURRENT-- ))\n")
+		if cmd.name == "greet" {
+			b.WriteString("        _arguments $greet_flags '*: :'\n        ;;\n")
+			continue
+		}
+		b.WriteString("        _arguments")
+		for _, spec := range zshFlagSpecs(cmd) {
+			b.WriteString(" " + spec)
+		}
+		if takesFiles(cmd) {
+			b.WriteString(" '*: :_files'")
+		}
+		b.WriteString("\n        ;;\n")
+	}
+	b.WriteString("    *)\n        _arguments $greet_flags '*: :'\n        ;;\n")
+	b.WriteString("    esac\n}\n")
+	// autoloaded from $fpath the file is run to complete, sourced it
+	// registers fn
+	fmt.Fprintf(&b, "if [[ $zsh_eval_context[-1] == loadautofunc ]]; then\n    %s \"$@\"\nelse\n    compdef %s %s\nfi\n", fn, fn, prog)
+	return b.String()
+}
+
+// zshFlagSpecs are the flags of cmd as quoted _arguments specs, like
+// '--times[number of times to greet]:count: '.
+func zshFlagSpecs(cmd command) []string {
+	brackets := strings.NewReplacer("[", `\[`, "]", `\]`)
+	specs := []string{}
+	for _, f := range completionFlags(cmd) {
+		spec := "[" + brackets.Replace(f.usage) + "]"
+		if f.arg != "" {
+			action := " "
+			if f.files() {
+				action = "_files"
+			} else if choices := f.choices(); choices != nil {
+				action = "(" + strings.Join(choices, " ") + ")"
+			}
+			spec += ":" + strings.ReplaceAll(f.arg, ":", `\:`) + ":" + action
+		}
+		specs = append(specs, shellQuote(dashes(f.name)+spec))
+		if f.short != "" {
+			specs = append(specs, shellQuote(dashes(f.short)+spec))
+		}
+	}
+	return specs
+}
+
+// fishCompletion is installed as <prog>.fish in a vendor_completions.d
+// directory, or loaded with name-cli completion fish | source.
+func fishCompletion(prog string) string {
+	others := []string{}
+	for _, cmd := range commands {
+		if cmd.name != "greet" {
+			others = append(others, cmd.name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s, generated by '%s completion fish'\n", prog, prog)
+	fmt.Fprintf(&b, "complete -c %s -f\n", prog)
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n", prog, cmd.name, shellQuote(cmd.summary))
+	}
+	for _, cmd := range commands {
+		// greet also runs without naming it
+		cond := "__fish_seen_subcommand_from " + cmd.name
+		if cmd.name == "greet" {
+			cond = "not __fish_seen_subcommand_from " + strings.Join(others, " ")
+		} else if takesFiles(cmd) {
+			fmt.Fprintf(&b, "complete -c %s -n %s -F\n", prog, shellQuote(cond))
+		}
+		for _, f := range completionFlags(cmd) {
+			fmt.Fprintf(&b, "complete -c %s -n %s", prog, shellQuote(cond))
+			if len(f.name) == 1 {
+				fmt.Fprintf(&b, " -s %s", f.name)
+			} else {
+				fmt.Fprintf(&b, " -l %s", f.name)
+			}
+			if f.short != "" {
+				fmt.Fprintf(&b, " -s %s", f.short)
+			}
+			if f.arg != "" {
+				if f.files() {
+					b.WriteString(" -r -F")
+				} else if choices := f.choices(); choices != nil {
+					fmt.Fprintf(&b, " -x -a %s", shellQuote(strings.Join(choices, " ")))
+				} else {
+					b.WriteString(" -x")
+				}
+			}
+			fmt.Fprintf(&b, " -d %s\n", shellQuote(f.usage))
+		}
+	}
+	return b.String()
+}
+
+// powershellCompletion is loaded from the profile, with
+// name-cli completion powershell | Out-String | Invoke-Expression.
+func powershellCompletion(prog string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# powershell completion for %s, generated by '%s completion powershell'\n", prog, prog)
+	fmt.Fprintf(&b, "Register-ArgumentCompleter -Native -CommandName %s, %s -ScriptBlock {\n", psQuote(prog), psQuote(prog+".exe"))
+	b.WriteString("    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	names := []string{}
+	for _, cmd := range commands {
+		names = append(names, psQuote(cmd.name))
+	}
+	fmt.Fprintf(&b, "    $commands = @(%s)\n", strings.Join(names, ", "))
+	b.WriteString("    $flags = @{\n")
+	for _, cmd := range commands {
+		flags := []string{}
+		for _, f := range commandFlags(cmd) {
+			flags = append(flags, psQuote(f))
+		}
+		fmt.Fprintf(&b, "        %s = @(%s)\n", psQuote(cmd.name), strings.Join(flags, ", "))
+	}
+	b.WriteString("    }\n")
+	b.WriteString("    $elements = $commandAst.CommandElements\n")
+	b.WriteString("    if ($elements.Count -eq 1 -or ($elements.Count -eq 2 -and $wordToComplete -ne '')) {\n")
+	b.WriteString("        $words = $commands + $flags['greet']\n")
+	b.WriteString("    } elseif ($flags.ContainsKey($elements[1].ToString())) {\n")
+	b.WriteString("        $words = $flags[$elements[1].ToString()]\n")
+	b.WriteString("    } else {\n")
+	b.WriteString("        $words = $flags['greet']\n")
+	b.WriteString("    }\n")
+	b.WriteString("    $words | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	b.WriteString("        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	b.WriteString("    }\n}\n")
+	return b.String()
+}
+
+// shellQuote quotes s in single quotes for zsh and fish.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBashCompletion(t *testing.T) {
+	script := bashCompletion("name-cli")
+	wants := []string{"complete -F _name_cli name-cli\n"}
+	if hasServe && hasBadge {
+		wants = append(wants, "greet serve loadtest display badge lint diff history stats state examples version assets service features completion internal --allowlist")
+	}
+	for _, want := range wants {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected completion to contain %q, got:\n%s\n", want, script)
+		}
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	path := filepath.Join(t.TempDir(), "name-cli.bash")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command(bash, "-n", path).CombinedOutput(); err != nil {
+		t.Errorf("expected a valid bash script, got: %v\n%s", err, out)
+	}
+}
+
+func TestCompletionsDir(t *testing.T) {
+	prefix := t.TempDir()
+	keg := filepath.Join(prefix, "Cellar", "name-cli", "1.2.3")
+	if err := os.MkdirAll(filepath.Join(keg, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(keg, "bin", "name-cli"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := completionsDir(filepath.Join(keg, "bin", "name-cli"), "bash"); got != filepath.Join(keg, "share", "bash-completion", "completions") {
+		t.Errorf("expected the share directory next to bin, got: %v\n", got)
+	}
+
+	for shell, dir := range map[string]string{"zsh": "zsh/site-functions", "fish": "fish/vendor_completions.d"} {
+		if got, _ := completionsDir(filepath.Join(keg, "bin", "name-cli"), shell); got != filepath.Join(keg, "share", filepath.FromSlash(dir)) {
+			t.Errorf("%s: expected %v in share, got: %v\n", shell, dir, got)
+		}
+	}
+	if _, err := completionsDir(filepath.Join(keg, "bin", "name-cli"), "powershell"); err == nil {
+		t.Error("expected an error for powershell, which has no completions directory")
+	}
+
+	// a linked binary installs into the keg it links to
+	if err := os.MkdirAll(filepath.Join(prefix, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(prefix, "bin", "name-cli")
+	if err := os.Symlink(filepath.Join(keg, "bin", "name-cli"), link); err != nil {
+		t.Skip("no symlinks here")
+	}
+	want, _ := filepath.EvalSymlinks(filepath.Join(keg, "bin", "name-cli"))
+	want = filepath.Join(filepath.Dir(filepath.Dir(want)), "share", "bash-completion", "completions")
+	if got, _ := completionsDir(link, "bash"); got != want {
+		t.Errorf("expected %v, got: %v\n", want, got)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	tests := []struct {
+		shell string
+		wants []string
+		check []string // a command checking the syntax of the script
+	}{
+		{
+			shell: "zsh",
+			wants: []string{
+				"#compdef name-cli\n",
+				"        'lint:check a file of names for --stdin-batch for empty lines, odd characters and duplicates'\n",
+				"'--output[print the greetings as text|json, json being one object per line with the name, greeting and index]:text|json:(text json)'",
+				"'-n[number of times to greet, instead of giving the count as an argument]:count: '",
+				"'--at[wait until the given time of day (HH:MM) before greeting]:HH\\:MM: '",
+				"'--allowlist[only greet names listed in file, one per line]:file:_files'",
+				"compdef _name_cli name-cli\n",
+			},
+			check: []string{"zsh", "-n"},
+		},
+		{
+			shell: "fish",
+			wants: []string{
+				"complete -c name-cli -n __fish_use_subcommand -a diff -d 'compare two --report files, or the greetings of two runs with --output json'\n",
+				" -l times -s n -x -d 'number of times to greet, instead of giving the count as an argument'\n",
+				" -l output -x -a 'text json' -d ",
+				"complete -c name-cli -n '__fish_seen_subcommand_from lint' -F\n",
+			},
+			check: []string{"fish", "-n"},
+		},
+		{
+			shell: "powershell",
+			wants: []string{
+				"Register-ArgumentCompleter -Native -CommandName 'name-cli', 'name-cli.exe' -ScriptBlock {\n",
+				"        'lint' = @('--help', '-h')\n",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		var b bytes.Buffer
+		if err := printCompletion(&b, "name-cli.exe", tc.shell); err != nil {
+			t.Fatalf("%s: expected nil error, got: %v\n", tc.shell, err)
+		}
+		script := b.String()
+		for _, want := range tc.wants {
+			if !strings.Contains(script, want) {
+				t.Errorf("%s: expected completion to contain %q, got:\n%s\n", tc.shell, want, script)
+			}
+		}
+		// flags that are only meant for testing stay hidden
+		if strings.Contains(script, "fail-after") {
+			t.Errorf("%s: expected no hidden flags, got:\n%s\n", tc.shell, script)
+		}
+
+		if tc.check == nil {
+			continue
+		}
+		bin, err := exec.LookPath(tc.check[0])
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(t.TempDir(), "name-cli."+tc.shell)
+		if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command(bin, append(tc.check[1:], path)...).CombinedOutput(); err != nil {
+			t.Errorf("expected a valid %s script, got: %v\n%s", tc.shell, err, out)
+		}
+	}
+}
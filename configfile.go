@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const originConfig = "config file"
+
+// configFileNames are looked for in userConfigDir, the first one
+// found is used.
+var configFileNames = []string{"config.yaml", "config.toml"}
+
+// configOption is one "key: value" or "key = value" line of a config file.
+type configOption struct {
+	key, value string
+	line       int
+}
+
+// userConfigDir is name-cli in the platform's config directory:
+// ~/.config, ~/Library/Application Support on macOS and %AppData% on
+// Windows, or $XDG_CONFIG_HOME if set. It is "" if there is no home
+// directory.
+func userConfigDir() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return ""
+		}
+	}
+	return filepath.Join(dir, "name-cli")
+}
+
+// findConfigFile returns the path of the config file in the user's config
+// directory, or "" if there is none.
+func findConfigFile() string {
+	dir := userConfigDir()
+	if dir == "" {
+		return ""
+	}
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadConfigFile reads the flat subset of YAML and TOML the config file
+// needs: one option per line, named like the flag, with the value after
+// a colon or an equals sign. Values may be quoted, # starts a comment.
+func loadConfigFile(path string) ([]configOption, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	opts := []configOption{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("%s:%d: sections are not supported", path, n)
+		}
+		i := strings.IndexAny(line, ":=")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected key: value", path, n)
+		}
+		key := strings.TrimSpace(line[:i])
+		value, err := configValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		opts = append(opts, configOption{key: key, value: value, line: n})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+func configValue(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := strings.LastIndex(s, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strconv.Unquote(s[:end+1])
+	case strings.HasPrefix(s, "'"):
+		end := strings.LastIndex(s, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1:end], nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// applyConfigFile sets the options of the config file that weren't set
+// yet, see resolveConfig. It uses c.configFile if set, otherwise the file in
+// the user's config directory if there is one.
+func applyConfigFile(fs *flag.FlagSet, c *config) error {
+	path := c.configFile
+	if path == "" {
+		if path = findConfigFile(); path == "" {
+			return nil
+		}
+		c.configFile = path
+	}
+	opts, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	for _, o := range opts {
+		_, alias := flagAliases[o.key]
+		if fs.Lookup(o.key) == nil || alias || exitFlags[o.key] || o.key == "config" {
+			return fmt.Errorf("%s:%d: unknown option %q", path, o.line, o.key)
+		}
+		if c.origin[o.key] != "" {
+			continue
+		}
+		if err := fs.Set(o.key, o.value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value %q for %s: %v", path, o.line, o.value, o.key, err)
+		}
+		c.setOrigin(o.key, originConfig)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		opts    []configOption
+		err     string
+	}{
+		{
+			content: "# defaults\n---\ntimes: 3\nname: \"Benny Engstrom\"  # me\nfallback: 'Welcome, guest!'\nat: 17:00\n",
+			opts: []configOption{
+				{key: "times", value: "3", line: 3},
+				{key: "name", value: "Benny Engstrom", line: 4},
+				{key: "fallback", value: "Welcome, guest!", line: 5},
+				{key: "at", value: "17:00", line: 6},
+			},
+		},
+		{
+			content: "times = 3\nfilter = \"mask\"\nsession = true # kiosk\n",
+			opts: []configOption{
+				{key: "times", value: "3", line: 1},
+				{key: "filter", value: "mask", line: 2},
+				{key: "session", value: "true", line: 3},
+			},
+		},
+		{content: "[greet]\ntimes = 3\n", err: "config:1: sections are not supported"},
+		{content: "times\n", err: "config:1: expected key: value"},
+		{content: "name: \"Benny\n", err: `config:1: unterminated string "Benny`},
+	}
+
+	for _, tc := range tests {
+		path := writeConfigFile(t, dir, "config", tc.content)
+		opts, err := loadConfigFile(path)
+		if tc.err != "" {
+			if err == nil || err.Error() != filepath.Join(dir, tc.err) {
+				t.Errorf("expected error to be: %v, got: %v\n", filepath.Join(dir, tc.err), err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if !reflect.DeepEqual(opts, tc.opts) {
+			t.Errorf("expected options %+v, got: %+v\n", tc.opts, opts)
+		}
+	}
+}
+
+func TestConfigFilePrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	writeConfigFile(t, home, "name-cli/config.yaml", "times: 3\nname: Benny\nfilter: mask\n")
+	other := writeConfigFile(t, t.TempDir(), "other.toml", "times = 7\n")
+
+	tests := []struct {
+		args   []string
+		times  int
+		name   string
+		origin map[string]string
+	}{
+		{
+			args:   []string{},
+			times:  3,
+			name:   "Benny",
+			origin: map[string]string{"times": originConfig, "name": originConfig, "filter": originConfig},
+		},
+		{
+			args:   []string{"--name", "Ada", "5"},
+			times:  5,
+			name:   "Ada",
+			origin: map[string]string{"times": originFlag, "name": originFlag, "filter": originConfig},
+		},
+		{
+			args:   []string{"-n", "2"},
+			times:  2,
+			name:   "Benny",
+			origin: map[string]string{"times": originFlag, "name": originConfig, "filter": originConfig},
+		},
+		{
+			args:   []string{"--config", other},
+			times:  7,
+			origin: map[string]string{"times": originConfig, "config": originFlag},
+		},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(tc.args)
+		if err != nil {
+			t.Fatalf("%v: expected nil error, got: %v\n", tc.args, err)
+		}
+		if c.numTimes != tc.times || c.name != tc.name {
+			t.Errorf("%v: expected %d times and name %q, got: %d and %q\n", tc.args, tc.times, tc.name, c.numTimes, c.name)
+		}
+		if !reflect.DeepEqual(c.origin, tc.origin) {
+			t.Errorf("%v: expected origins %v, got: %v\n", tc.args, tc.origin, c.origin)
+		}
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		err     error
+	}{
+		{content: "colour: red\n", err: errors.New(filepath.Join(dir, "config.yaml") + `:1: unknown option "colour"`)},
+		{content: "help: true\n", err: errors.New(filepath.Join(dir, "config.yaml") + `:1: unknown option "help"`)},
+		{content: "n: 3\n", err: errors.New(filepath.Join(dir, "config.yaml") + `:1: unknown option "n"`)},
+		{content: "times: lots\n", err: errors.New(filepath.Join(dir, "config.yaml") + `:1: invalid value "lots" for times: parse error`)},
+	}
+
+	for _, tc := range tests {
+		path := writeConfigFile(t, dir, "config.yaml", tc.content)
+		_, err := parseArgs([]string{"--config", path})
+		if err == nil || err.Error() != tc.err.Error() {
+			t.Errorf("expected error to be: %v, got: %v\n", tc.err, err)
+		}
+	}
+
+	if _, err := parseArgs([]string{"--config", filepath.Join(dir, "missing.yaml"), "1"}); err == nil {
+		t.Error("expected an error for a missing --config file")
+	}
+	// without a file in the config directory there is nothing to apply
+	if _, err := parseArgs([]string{"1"}); err != nil {
+		t.Errorf("expected nil error, got: %v\n", err)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// diffRun is one side of a diff: a --report file, or the greetings of a
+// run with --output json.
+type diffRun struct {
+	path   string
+	report *runReport
+	// with --output json, how many lines each name got
+	names map[string]int
+	lines int
+}
+
+func parseDiffArgs(args []string) (config, error) {
+	c := config{command: "diff"}
+	rest, err := parseHelpArgs(&c, args)
+	if err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return c, nil
+	}
+	if len(rest) != 2 {
+		return config{}, ErrInvalidArgCount
+	}
+	c.diffFiles = rest
+	return c, nil
+}
+
+// errRunsDiffer makes diff exit with 1 like diff(1) when the runs aren't
+// the same.
+var errRunsDiffer = errors.New("the runs differ")
+
+// runDiff compares the two runs of c.diffFiles and writes what changed
+// from the first to the second on w. Timings are left out, they differ
+// every time.
+func runDiff(w io.Writer, c config) error {
+	a, err := loadDiffRun(c.diffFiles[0])
+	if err != nil {
+		return err
+	}
+	b, err := loadDiffRun(c.diffFiles[1])
+	if err != nil {
+		return err
+	}
+	if (a.report == nil) != (b.report == nil) {
+		return errors.New("can't compare a --report file with --output json greetings")
+	}
+
+	var changes int
+	if a.report != nil {
+		changes = diffReports(w, a.report, b.report)
+	} else {
+		changes = diffGreetings(w, a, b)
+	}
+	if changes > 0 {
+		return errRunsDiffer
+	}
+	return nil
+}
+
+// loadDiffRun reads a run report, told apart from greetings by its
+// schema_version, or newline delimited greetingRecords.
+func loadDiffRun(path string) (*diffRun, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read run: %w", err)
+	}
+	run := &diffRun{path: path}
+	var probe struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if json.Unmarshal(data, &probe) == nil && probe.SchemaVersion != nil {
+		run.report = &runReport{}
+		if err := json.Unmarshal(data, run.report); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return run, nil
+	}
+
+	run.names = map[string]int{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var g greetingRecord
+		if err := json.Unmarshal(scanner.Bytes(), &g); err != nil || g.Name == "" {
+			return nil, fmt.Errorf("%s:%d: expected a run report or greetings from --output json", path, line)
+		}
+		run.names[g.Name]++
+		run.lines++
+	}
+	return run, scanner.Err()
+}
+
+// diffReports writes the fields of two reports that differ and returns
+// how many did.
+func diffReports(w io.Writer, a, b *runReport) int {
+	fields := []struct {
+		name string
+		a, b interface{}
+	}{
+		{"num_times", a.Config.NumTimes, b.Config.NumTimes},
+		{"visitors", a.Visitors, b.Visitors},
+		{"greetings", a.Greetings, b.Greetings},
+		{"success", a.Success, b.Success},
+		{"error", a.Error, b.Error},
+	}
+	changes := 0
+	for _, f := range fields {
+		if f.a != f.b {
+			fmt.Fprintf(w, "%s: %v -> %v\n", f.name, quoteString(f.a), quoteString(f.b))
+			changes++
+		}
+	}
+	return changes
+}
+
+func quoteString(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return v
+}
+
+// diffGreetings writes the names greeted in only one of the runs, with -
+// and +, and those greeted a different number of times, followed by the
+// total if it changed. It returns how many lines it wrote.
+func diffGreetings(w io.Writer, a, b *diffRun) int {
+	names := []string{}
+	for name := range a.names {
+		names = append(names, name)
+	}
+	for name := range b.names {
+		if _, ok := a.names[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := 0
+	for _, name := range names {
+		na, nb := a.names[name], b.names[name]
+		switch {
+		case na == nb:
+			continue
+		case nb == 0:
+			fmt.Fprintf(w, "- %s (%d)\n", name, na)
+		case na == 0:
+			fmt.Fprintf(w, "+ %s (%d)\n", name, nb)
+		default:
+			fmt.Fprintf(w, "~ %s (%d -> %d)\n", name, na, nb)
+		}
+		changes++
+	}
+	if a.lines != b.lines {
+		fmt.Fprintf(w, "greetings: %d -> %d\n", a.lines, b.lines)
+		changes++
+	}
+	return changes
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDiffArgs(t *testing.T) {
+	c, err := parseArgs([]string{"diff", "a.json", "b.json"})
+	if err != nil || c.command != "diff" || len(c.diffFiles) != 2 || c.diffFiles[1] != "b.json" {
+		t.Errorf("expected both files, got: %+v, %v\n", c, err)
+	}
+	if _, err := parseArgs([]string{"diff", "a.json"}); !errors.Is(err, ErrInvalidArgCount) {
+		t.Errorf("expected error: %v, got: %v\n", ErrInvalidArgCount, err)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	reportA := write("a.json", `{"schema_version": 1, "config": {"num_times": 3}, "greetings": 3, "duration_ms": 12, "success": true}`)
+	reportB := write("b.json", `{"schema_version": 1, "config": {"num_times": 3}, "greetings": 0, "duration_ms": 40, "success": false, "error": "you didn't enter your name"}`)
+	reportC := write("c.json", `{"schema_version": 1, "config": {"num_times": 3}, "greetings": 3, "duration_ms": 99, "success": true}`)
+	greetA := write("a.ndjson", `{"name":"Benny","greeting":"Hi Benny","index":1}
+{"name":"Benny","greeting":"Hi Benny","index":2}
+{"name":"Ada","greeting":"Hi Ada","index":1}
+`)
+	greetB := write("b.ndjson", `{"name":"Benny","greeting":"Hi Benny","index":1}
+{"name":"Grace","greeting":"Hi Grace","index":1}
+{"name":"Grace","greeting":"Hi Grace","index":2}
+`)
+	garbage := write("notes.txt", "Benny\n")
+
+	tests := []struct {
+		files  []string
+		output string
+		err    error
+	}{
+		{
+			files:  []string{reportA, reportB},
+			output: "greetings: 3 -> 0\nsuccess: true -> false\nerror: \"\" -> \"you didn't enter your name\"\n",
+			err:    errRunsDiffer,
+		},
+		// only the timings differ
+		{files: []string{reportA, reportC}},
+		{
+			files:  []string{greetA, greetB},
+			output: "- Ada (1)\n~ Benny (2 -> 1)\n+ Grace (2)\n",
+			err:    errRunsDiffer,
+		},
+		{files: []string{greetA, greetA}},
+		{files: []string{reportA, greetA}, err: errors.New("can't compare a --report file with --output json greetings")},
+		{files: []string{garbage, greetA}, err: errors.New(garbage + ":1: expected a run report or greetings from --output json")},
+	}
+
+	for _, tc := range tests {
+		out := new(bytes.Buffer)
+		err := runDiff(out, config{diffFiles: tc.files})
+		if tc.err == nil && err != nil {
+			t.Errorf("%v: expected nil error, got: %v\n", tc.files, err)
+		}
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Errorf("%v: expected error: %v, got: %v\n", tc.files, tc.err, err)
+		}
+		if out.String() != tc.output {
+			t.Errorf("%v: expected:\n%s\ngot:\n%s\n", tc.files, tc.output, out.String())
+		}
+	}
+}
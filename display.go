@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultHold = 5 * time.Second
+	// clearScreen moves the cursor home and clears the terminal.
+	clearScreen = "\033[H\033[2J"
+	// the size assumed when the terminal can't tell us
+	defaultCols = 80
+	defaultRows = 24
+)
+
+func newDisplayFlagSet(c *config) *flag.FlagSet {
+	fs := flag.NewFlagSet("display", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&c.printUsage, "help", c.printUsage, "show this help and exit")
+	fs.BoolVar(&c.printUsage, "h", c.printUsage, "show this help and exit")
+	fs.StringVar(&c.lang, "lang", c.lang, "`language` of the prompt and greeting, instead of the one from $LANG")
+	fs.DurationVar(&c.hold, "hold", c.hold, "how long each greeting stays on screen")
+	fs.BoolVar(&c.pronounce, "show-pronunciation", c.pronounce, "show how the name is pronounced, from the allowlist, below the greeting")
+	fs.StringVar(&c.printer, "printer", c.printer, "also print each greeting on the ESC/POS receipt printer at `device|tcp://host:port`")
+	fs.BoolVar(&c.noHistory, "no-history", c.noHistory, "don't add the visitors to the history, which the history command lists")
+	addNameCheckFlags(fs, c)
+	addSuppressFlags(fs, c)
+	return fs
+}
+
+func displayDefaults() config {
+	c := defaultConfig()
+	c.command = "display"
+	c.hold = defaultHold
+	c.numTimes = 1
+	return c
+}
+
+func parseDisplayArgs(args []string) (config, error) {
+	c := displayDefaults()
+	fs := newDisplayFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return config{printUsage: true, command: "display"}, nil
+	}
+	if err := resolveEnv(fs, &c); err != nil {
+		return config{}, err
+	}
+	if fs.NArg() != 0 {
+		return config{}, ErrInvalidArgCount
+	}
+	if c.denylistFile != "" && c.filterMode == "" {
+		c.filterMode = filterReject
+	}
+	return c, nil
+}
+
+func validateDisplayArgs(c config) error {
+	if c.hold <= 0 {
+		return errors.New("--hold must be greater than 0")
+	}
+	if c.pronounce && c.allowlistFile == "" {
+		return errors.New("--show-pronunciation needs an --allowlist to read pronunciations from")
+	}
+	return validateNameChecks(c)
+}
+
+// terminalSize returns the size of the terminal w writes to, falling back
+// to $COLUMNS and $LINES and then to 80x24.
+func terminalSize(w io.Writer) (cols, rows int) {
+	if f, ok := w.(*os.File); ok && isTerminal(w) {
+		if cols, rows, ok := ttySize(f); ok {
+			return cols, rows
+		}
+	}
+	cols, rows = defaultCols, defaultRows
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		cols = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		rows = n
+	}
+	return cols, rows
+}
+
+// displayLines picks the largest way of showing the greeting that fits
+// in cols: all of it in fnt, just the name, or plain text.
+func displayLines(fnt *font, greeting, name string, cols int) []string {
+	if rows, ok := fnt.render(greeting + " " + name); ok && textWidth(rows[0]) <= cols {
+		return rows
+	}
+	if rows, ok := fnt.render(name); ok && textWidth(rows[0]) <= cols {
+		return append([]string{greeting, ""}, rows...)
+	}
+	return []string{greeting + " " + name}
+}
+
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// writeCentered clears the screen and writes lines centered on a
+// cols x rows terminal.
+func writeCentered(w io.Writer, lines []string, cols, rows int) {
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	if pad := (rows - len(lines)) / 2; pad > 0 {
+		b.WriteString(strings.Repeat("\n", pad))
+	}
+	for _, line := range lines {
+		if pad := (cols - textWidth(line)) / 2; pad > 0 && line != "" {
+			b.WriteString(strings.Repeat(" ", pad))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	fmt.Fprint(w, b.String())
+}
+
+// runDisplay is a reception desk mode: it asks each visitor for their
+// name and shows the greeting full screen for c.hold before asking the
+// next one, printing a welcome slip too if c.printer is set. It stops
+// when the input is closed or it's interrupted while showing a greeting,
+// and returns how many visitors were greeted.
+func runDisplay(ctx context.Context, r io.Reader, w io.Writer, c config) (int, error) {
+	fnt, err := loadFont("block")
+	if err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(r)
+	cols, rows := terminalSize(w)
+	visitors := 0
+	// leave a clean terminal behind
+	defer fmt.Fprint(w, clearScreen)
+	for {
+		fmt.Fprint(w, clearScreen)
+		name, err := checkName(scanner, w, c)
+		// closing stdin or Ctrl+C is how the desk stops the display
+		if err == io.EOF || ctx.Err() != nil {
+			return visitors, nil
+		}
+		if err == errSuppressed {
+			continue
+		}
+		var lines []string
+		if err != nil {
+			if scanner.Err() != nil {
+				return visitors, err
+			}
+			lines = []string{c.messages().errorText(err)}
+		} else {
+			visitors++
+			if c.greeted != nil {
+				*c.greeted++
+			}
+			lines = displayLines(fnt, c.messages().greeting, name, cols)
+			if err := recordGreeting(c, name, 1); err != nil {
+				// like the printer, not worth turning the visitor away for
+				lines = append(lines, "", err.Error())
+			}
+			if c.pronounce {
+				if p, err := pronunciation(c, name); err == nil && p != "" {
+					lines = append(lines, "", "("+p+")")
+				}
+			}
+			if c.printer != "" {
+				if err := printSlip(c.printer, c.messages().greeting, name, time.Now()); err != nil {
+					// keep greeting on screen, but let the desk know
+					lines = append(lines, "", "printer: "+err.Error())
+				}
+			}
+		}
+		writeCentered(w, lines, cols, rows)
+		if err := holdScreen(ctx, c.hold); err != nil {
+			return visitors, nil
+		}
+	}
+}
+
+// holdScreen waits for d, returning errScheduleCancelled if interrupted.
+func holdScreen(ctx context.Context, d time.Duration) error {
+	return waitUntil(ctx, io.Discard, time.Now().Add(d), false)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDisplayArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+		c    config
+	}{
+		{
+			args: []string{},
+			c:    config{command: "display", hold: defaultHold},
+		},
+		{
+			args: []string{"--hold", "10s", "--denylist", "words.txt"},
+			c:    config{command: "display", hold: 10 * time.Second, filterMode: filterReject},
+		},
+		{
+			args: []string{"-h"},
+			c:    config{printUsage: true, command: "display"},
+		},
+		{
+			args: []string{"Benny"},
+			err:  ErrInvalidArgCount,
+		},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"display"}, tc.args...))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("expected error to be: %v, got: %v\n", tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if c.command != tc.c.command || c.hold != tc.c.hold || c.filterMode != tc.c.filterMode || c.printUsage != tc.c.printUsage {
+			t.Errorf("expected config to be: %+v, got: %+v\n", tc.c, c)
+		}
+	}
+}
+
+func TestValidateDisplayArgs(t *testing.T) {
+	c, _ := parseDisplayArgs([]string{"--hold", "0s"})
+	if err := validateArgs(c); err == nil || err.Error() != "--hold must be greater than 0" {
+		t.Errorf("expected --hold error, got: %v\n", err)
+	}
+	c, _ = parseDisplayArgs([]string{"--filter", "shout"})
+	if err := validateArgs(c); err == nil {
+		t.Error("expected an error for an unknown filter mode")
+	}
+}
+
+func TestDisplayLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		cols  int
+		lines int
+		first string
+	}{
+		// everything fits in large letters
+		{name: "Bo", cols: 200, lines: blockFont.height},
+		// only the name fits
+		{name: "Bo", cols: 20, lines: blockFont.height + 2, first: "Nice to meet you"},
+		// nothing fits
+		{name: "Bo", cols: 5, lines: 1, first: "Nice to meet you Bo"},
+		// no glyphs for these
+		{name: "Zoë", cols: 200, lines: 1, first: "Nice to meet you Zoë"},
+	}
+
+	for _, tc := range tests {
+		lines := displayLines(blockFont, "Nice to meet you", tc.name, tc.cols)
+		if len(lines) != tc.lines {
+			t.Errorf("%s in %d columns: expected %d lines, got: %q\n", tc.name, tc.cols, tc.lines, lines)
+			continue
+		}
+		if tc.first != "" && lines[0] != tc.first {
+			t.Errorf("%s in %d columns: expected first line %q, got: %q\n", tc.name, tc.cols, tc.first, lines[0])
+		}
+	}
+}
+
+func TestWriteCentered(t *testing.T) {
+	var b bytes.Buffer
+	writeCentered(&b, []string{"ab", "abcd"}, 10, 6)
+	expected := clearScreen + "\n\n    ab\n   abcd\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, got: %q\n", expected, b.String())
+	}
+}
+
+func TestRunDisplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guests.txt")
+	if err := os.WriteFile(path, []byte("Benny\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := parseDisplayArgs([]string{"--hold", "1ms", "--allowlist", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	n, err := runDisplay(context.Background(), strings.NewReader("Benny\nMallory\n"), &out, c)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 visitor, got: %d\n", n)
+	}
+	banner, _ := blockFont.render("Benny")
+	if !strings.Contains(out.String(), banner[0]) {
+		t.Errorf("expected the greeting in large letters, got: %q\n", out.String())
+	}
+	if !strings.Contains(out.String(), defaultFallbackMsg) {
+		t.Errorf("expected the fallback message for Mallory, got: %q\n", out.String())
+	}
+	if !strings.HasSuffix(out.String(), clearScreen) {
+		t.Error("expected the screen to be cleared at the end")
+	}
+}
+
+func TestRunDisplayHistory(t *testing.T) {
+	for _, noHistory := range []bool{false, true} {
+		t.Setenv("XDG_DATA_HOME", t.TempDir())
+		args := []string{"--hold", "1ms"}
+		if noHistory {
+			args = append(args, "--no-history")
+		}
+		c, err := parseDisplayArgs(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := runDisplay(context.Background(), strings.NewReader("Benny\nAda\n"), io.Discard, c); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		entries, err := openHistory().entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := map[bool]int{false: 2, true: 0}[noHistory]; len(entries) != expected {
+			t.Errorf("no history %v: expected %d entries, got: %+v\n", noHistory, expected, entries)
+		} else if !noHistory && (entries[0].Name != "Benny" || entries[1].Name != "Ada" || entries[1].Count != 1) {
+			t.Errorf("expected Benny and Ada greeted once, got: %+v\n", entries)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// --input-encoding settings. Shift-JIS and other multi-byte legacy
+// encodings would need conversion tables the standard library doesn't
+// have, so they aren't offered.
+const (
+	encodingAuto    = "auto"
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingLatin1  = "latin1"
+)
+
+var (
+	inputEncodings  = []string{encodingAuto, encodingUTF8, encodingUTF16LE, encodingUTF16BE, encodingLatin1}
+	outputEncodings = []string{encodingUTF8, encodingUTF16LE, encodingLatin1}
+)
+
+// byteOrderMarks start files in the Unicode encodings.
+var byteOrderMarks = map[string]string{
+	encodingUTF8:    "\xef\xbb\xbf",
+	encodingUTF16LE: "\xff\xfe",
+	encodingUTF16BE: "\xfe\xff",
+}
+
+// encodingName is enc as one of the encoding constants, which are also
+// accepted without the dash, as utf8 or utf16le.
+func encodingName(enc string) string {
+	enc = strings.ToLower(enc)
+	if strings.HasPrefix(enc, "utf") && !strings.HasPrefix(enc, "utf-") {
+		return "utf-" + enc[len("utf"):]
+	}
+	return enc
+}
+
+func validateInputEncoding(enc string) error {
+	if enc == "" || contains(inputEncodings, encodingName(enc)) {
+		return nil
+	}
+	return fmt.Errorf("unknown input encoding %q, expected one of: %s", enc, strings.Join(inputEncodings, ", "))
+}
+
+// validateOutputEncoding checks --output-encoding and --output-bom.
+func validateOutputEncoding(c config) error {
+	if (c.outEncoding != "" || c.outputBOM != "") && c.outputFile == "" {
+		return errors.New("--output-encoding and --output-bom need an --output-file to write to")
+	}
+	enc := encodingName(c.outEncoding)
+	if c.outEncoding != "" && !contains(outputEncodings, enc) {
+		return fmt.Errorf("unknown output encoding %q, expected one of: %s", c.outEncoding, strings.Join(outputEncodings, ", "))
+	}
+	switch c.outputBOM {
+	case "", bomAuto, bomNever:
+	case bomAlways:
+		if _, ok := byteOrderMarks[enc]; !ok && c.outEncoding != "" {
+			return fmt.Errorf("%s has no byte order mark", c.outEncoding)
+		}
+	default:
+		return fmt.Errorf("unknown --output-bom %q, expected %s, %s or %s", c.outputBOM, bomAuto, bomAlways, bomNever)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// --output-bom settings
+const (
+	bomAuto   = "auto" // for UTF-16 only, which can't be read without one
+	bomAlways = "always"
+	bomNever  = "never"
+)
+
+// cp1252 are the characters Windows-1252 has at 0x80 to 0x9f, where
+// Latin-1 has control characters nobody puts in a roster. Unassigned
+// bytes are kept as the control characters.
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// decodingReader converts what it reads from r to UTF-8. With
+// encodingAuto it looks for a byte order mark or the zero bytes of UTF-16
+// in the first read, and otherwise passes ASCII through until it comes
+// across a byte that isn't valid UTF-8, which makes the rest Latin-1.
+// Past the first three bytes nothing is read ahead, so a name piped in
+// line by line is seen as soon as it arrives.
+type decodingReader struct {
+	r       io.Reader
+	enc     string
+	started bool
+	buf     []byte
+	in      []byte // read but not converted yet, an incomplete character
+	out     []byte // converted but not returned yet
+	err     error
+}
+
+// decodeInput returns a reader of r converted from enc to UTF-8,
+// encodingAuto if enc is "".
+func decodeInput(r io.Reader, enc string) io.Reader {
+	if enc == "" {
+		enc = encodingAuto
+	}
+	return &decodingReader{r: r, enc: encodingName(enc), buf: make([]byte, 4096)}
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			if len(d.in) == 0 {
+				return 0, d.err
+			}
+			d.finish()
+			break
+		}
+		n, err := d.r.Read(d.buf)
+		d.err = err
+		d.convert(d.buf[:n])
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func (d *decodingReader) convert(chunk []byte) {
+	data := append(d.in, chunk...)
+	d.in = nil
+	if len(data) == 0 {
+		return
+	}
+	if !d.started {
+		if len(data) < 3 && d.err == nil {
+			// too little to tell a byte order mark from a name
+			d.in = data
+			return
+		}
+		d.started = true
+		data = d.detect(data)
+	}
+
+	switch d.enc {
+	case encodingUTF8:
+		d.out = append(d.out, data...)
+	case encodingLatin1:
+		d.out = appendLatin1(d.out, data)
+	case encodingUTF16LE, encodingUTF16BE:
+		d.out, d.in = appendUTF16(d.out, data, d.enc == encodingUTF16BE)
+	default:
+		complete := incompleteUTF8(data)
+		switch {
+		case !utf8.Valid(data[:complete]):
+			d.enc = encodingLatin1
+			d.out = appendLatin1(d.out, data)
+		case hasNonASCII(data[:complete]):
+			d.enc = encodingUTF8
+			d.out = append(d.out, data...)
+		default:
+			d.out = append(d.out, data[:complete]...)
+			d.in = append(d.in, data[complete:]...)
+		}
+	}
+}
+
+// detect settles the encoding from a byte order mark, dropping it, and
+// with encodingAuto from zero bytes that give away UTF-16.
+func (d *decodingReader) detect(data []byte) []byte {
+	for _, enc := range []string{encodingUTF8, encodingUTF16LE, encodingUTF16BE} {
+		mark := byteOrderMarks[enc]
+		if (d.enc == encodingAuto || d.enc == enc) && strings.HasPrefix(string(data), mark) {
+			d.enc = enc
+			return data[len(mark):]
+		}
+	}
+	if d.enc == encodingAuto && len(data) >= 2 {
+		// names are mostly Latin letters, with a zero high byte in UTF-16
+		switch {
+		case data[0] == 0 && data[1] != 0:
+			d.enc = encodingUTF16BE
+		case data[0] != 0 && data[1] == 0:
+			d.enc = encodingUTF16LE
+		}
+	}
+	return data
+}
+
+// finish converts what's left at the end of the input, a character cut
+// short becomes U+FFFD.
+func (d *decodingReader) finish() {
+	data := d.in
+	d.in = nil
+	if !d.started {
+		d.started = true
+		data = d.detect(data)
+	}
+	switch d.enc {
+	case encodingUTF8:
+		d.out = append(d.out, data...)
+	case encodingUTF16LE, encodingUTF16BE:
+		var rest []byte
+		d.out, rest = appendUTF16(d.out, data, d.enc == encodingUTF16BE)
+		if len(rest) > 0 {
+			d.out = utf8.AppendRune(d.out, utf8.RuneError)
+		}
+	default:
+		// not valid UTF-8 or it would have been converted already
+		d.out = appendLatin1(d.out, data)
+	}
+}
+
+func appendLatin1(dst, data []byte) []byte {
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			dst = append(dst, b)
+		case b < 0xa0:
+			dst = utf8.AppendRune(dst, cp1252[b-0x80])
+		default:
+			dst = utf8.AppendRune(dst, rune(b))
+		}
+	}
+	return dst
+}
+
+// appendUTF16 converts data, returning the bytes of a character that
+// continues in the next read.
+func appendUTF16(dst, data []byte, bigEndian bool) ([]byte, []byte) {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	rest := data[len(units)*2:]
+	if n := len(units); n > 0 && units[n-1] >= 0xd800 && units[n-1] < 0xdc00 {
+		// the first half of a surrogate pair
+		units = units[:n-1]
+		rest = data[(n-1)*2:]
+	}
+	for _, r := range utf16.Decode(units) {
+		dst = utf8.AppendRune(dst, r)
+	}
+	return dst, append([]byte(nil), rest...)
+}
+
+// incompleteUTF8 returns where a character cut off at the end of data
+// starts, or len(data).
+func incompleteUTF8(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
+
+func hasNonASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+// encodingWriter converts the UTF-8 written to it to enc before passing
+// it on to w. Characters Latin-1 doesn't have are written as '?'.
+type encodingWriter struct {
+	w     io.Writer
+	enc   string
+	carry []byte // the start of a character cut off by the last write
+	buf   []byte
+}
+
+// encodeOutput returns a writer converting to enc, and w itself for UTF-8.
+// The byte order mark is written first if bom asks for one.
+func encodeOutput(w io.Writer, enc, bom string) (io.Writer, error) {
+	enc = encodingName(enc)
+	if enc == "" {
+		enc = encodingUTF8
+	}
+	if mark, ok := byteOrderMarks[enc]; ok && (bom == bomAlways || (bom != bomNever && enc != encodingUTF8)) {
+		if _, err := io.WriteString(w, mark); err != nil {
+			return nil, err
+		}
+	}
+	if enc == encodingUTF8 {
+		return w, nil
+	}
+	return &encodingWriter{w: w, enc: enc}, nil
+}
+
+func (e *encodingWriter) Write(p []byte) (int, error) {
+	data := p
+	if len(e.carry) > 0 {
+		data = append(e.carry, p...)
+	}
+	complete := incompleteUTF8(data)
+	e.buf = e.buf[:0]
+	for i := 0; i < complete; {
+		r, size := utf8.DecodeRune(data[i:])
+		i += size
+		if e.enc == encodingUTF16LE {
+			if r >= 0x10000 {
+				r1, r2 := utf16.EncodeRune(r)
+				e.buf = append(e.buf, byte(r1), byte(r1>>8), byte(r2), byte(r2>>8))
+			} else {
+				e.buf = append(e.buf, byte(r), byte(r>>8))
+			}
+			continue
+		}
+		e.buf = append(e.buf, latin1Byte(r))
+	}
+	e.carry = append([]byte(nil), data[complete:]...)
+	if _, err := e.w.Write(e.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// latin1Byte is r in Latin-1, with the Windows-1252 characters at 0x80 to
+// 0x9f, or '?'.
+func latin1Byte(r rune) byte {
+	if r < 0x80 || (r >= 0xa0 && r <= 0xff) {
+		return byte(r)
+	}
+	for i, c := range cp1252 {
+		if c == r {
+			return byte(0x80 + i)
+		}
+	}
+	return '?'
+}
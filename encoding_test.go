@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		enc      string
+		expected string
+	}{
+		{input: "Benny\nJosé\n", expected: "Benny\nJosé\n"},
+		{input: "\xef\xbb\xbfJosé\n", expected: "José\n"},
+		{input: "Benny\nJos\xe9\n", expected: "Benny\nJosé\n"},
+		{input: "\x80 Zo\xeb\n", expected: "€ Zoë\n"},
+		{input: "\xff\xfeJ\x00o\x00s\x00\xe9\x00\n\x00", expected: "José\n"},
+		{input: "\xfe\xff\x00J\x00o\x00s\x00\xe9\x00\n", expected: "José\n"},
+		{input: "J\x00o\x00\n\x00", expected: "Jo\n"},
+		{input: "\x00J\x00o\x00\n", expected: "Jo\n"},
+		// a character outside the BMP, as a surrogate pair
+		{input: "\xff\xfe=\xd8\x00\xdeA\x00", expected: "😀A"},
+		{input: "J\x00o\x00", enc: encodingUTF16LE, expected: "Jo"},
+		{input: "Jos\xc3\xa9", enc: encodingLatin1, expected: "JosÃ©"},
+		{input: "Jos\xe9", enc: encodingUTF8, expected: "Jos\xe9"},
+		{input: "J\x00o", enc: encodingUTF16LE, expected: "J�"},
+		{input: "A", expected: "A"},
+		{input: "", expected: ""},
+	}
+
+	for _, tc := range tests {
+		// one byte at a time as well, to cut characters in half
+		for _, r := range []io.Reader{strings.NewReader(tc.input), iotest.OneByteReader(strings.NewReader(tc.input))} {
+			got, err := io.ReadAll(decodeInput(r, tc.enc))
+			if err != nil {
+				t.Fatalf("%q: expected nil error, got: %v\n", tc.input, err)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("%q as %q: expected %q, got: %q\n", tc.input, tc.enc, tc.expected, got)
+			}
+		}
+	}
+}
+
+func TestInputEncodingRun(t *testing.T) {
+	allowlist := filepath.Join(t.TempDir(), "guests.txt")
+	if err := os.WriteFile(allowlist, []byte("\xff\xfeJ\x00o\x00s\x00\xe9\x00\n\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := parseArgs([]string{"--stdin-batch", "--allowlist", allowlist, "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if _, err := runCommand(context.Background(), strings.NewReader("Jos\xe9\n"), out, c); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if out.String() != "Nice to meet you José\n" {
+		t.Errorf("expected José to be found on the allowlist, got: %q\n", out.String())
+	}
+
+	err = validateArgs(withDefaults(config{numTimes: 1, inputEncoding: "shift-jis"}))
+	expected := errors.New(`unknown input encoding "shift-jis", expected one of: auto, utf-8, utf-16le, utf-16be, latin1`)
+	if err == nil || err.Error() != expected.Error() {
+		t.Errorf("expected error: %v, got: %v\n", expected, err)
+	}
+}
+
+func TestEncodeOutput(t *testing.T) {
+	tests := []struct {
+		enc      string
+		bom      string
+		input    string
+		expected string
+	}{
+		{input: "José\n", expected: "José\n"},
+		{enc: "utf8", bom: bomAlways, input: "José\n", expected: "\xef\xbb\xbfJosé\n"},
+		{enc: encodingUTF16LE, input: "José\n", expected: "\xff\xfeJ\x00o\x00s\x00\xe9\x00\n\x00"},
+		{enc: "utf16le", bom: bomNever, input: "😀", expected: "=\xd8\x00\xde"},
+		{enc: encodingLatin1, input: "José € 5 – Zoë 李\n", expected: "Jos\xe9 \x80 5 \x96 Zo\xeb ?\n"},
+		{enc: encodingLatin1, bom: bomAuto, input: "é", expected: "\xe9"},
+	}
+
+	for _, tc := range tests {
+		out := new(bytes.Buffer)
+		w, err := encodeOutput(out, tc.enc, tc.bom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a byte at a time, to cut characters in half
+		for i := 0; i < len(tc.input); i++ {
+			if n, err := w.Write([]byte{tc.input[i]}); n != 1 || err != nil {
+				t.Fatalf("%q: expected the byte to be written, got: %d, %v\n", tc.input, n, err)
+			}
+		}
+		if out.String() != tc.expected {
+			t.Errorf("%q as %s: expected %q, got: %q\n", tc.input, tc.enc, tc.expected, out.String())
+		}
+	}
+}
+
+func TestValidateOutputEncoding(t *testing.T) {
+	tests := []struct {
+		c   config
+		err error
+	}{
+		{c: config{outputFile: "out.txt", outEncoding: "utf16le", outputBOM: bomAlways}},
+		{c: config{outputFile: "out.txt", outEncoding: encodingLatin1}},
+		{c: config{outEncoding: encodingLatin1}, err: errors.New("--output-encoding and --output-bom need an --output-file to write to")},
+		{c: config{outputFile: "out.txt", outEncoding: "shift-jis"}, err: errors.New(`unknown output encoding "shift-jis", expected one of: utf-8, utf-16le, latin1`)},
+		{c: config{outputFile: "out.txt", outEncoding: encodingLatin1, outputBOM: bomAlways}, err: errors.New("latin1 has no byte order mark")},
+		{c: config{outputFile: "out.txt", outputBOM: "sometimes"}, err: errors.New(`unknown --output-bom "sometimes", expected auto, always or never`)},
+	}
+
+	for _, tc := range tests {
+		err := validateOutputEncoding(tc.c)
+		if tc.err == nil && err != nil {
+			t.Errorf("%+v: expected nil error, got: %v\n", tc.c, err)
+		}
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Errorf("%+v: expected error: %v, got: %v\n", tc.c, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// ESC/POS commands understood by most receipt printers.
+const (
+	escInit       = "\x1b@"
+	escCenter     = "\x1ba\x01"
+	escBigText    = "\x1d!\x11" // double width and height
+	escNormalText = "\x1d!\x00"
+	escFeedCut    = "\x1dVB\x03" // feed 3 lines and do a partial cut
+)
+
+// printerTimeout bounds how long a printer gets to answer and take a
+// slip, so a switched off or jammed printer doesn't hold up the kiosk.
+const printerTimeout = 3 * time.Second
+
+// printer is a network connection or a device file, both of which can
+// be given a deadline.
+type printer interface {
+	io.WriteCloser
+	SetDeadline(t time.Time) error
+}
+
+// cp437 is the upper half of code page 437, the character set receipt
+// printers start up with. Index i is byte 0x80+i.
+const cp437 = "ÇüéâäàåçêëèïîìÄÅÉæÆôöòûùÿÖÜ¢£¥₧ƒáíóúñÑªº¿⌐¬½¼¡«»░▒▓│┤╡╢╖╕╣║╗╝╜╛┐└┴┬├─┼╞╟╚╔╩╦╠═╬╧╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀αßΓπΣσµτΦΘΩδ∞φε∩≡±≥≤⌠⌡÷≈°∙·√ⁿ²■ "
+
+var cp437Bytes = func() map[rune]byte {
+	m := map[rune]byte{}
+	i := 0
+	for _, r := range cp437 {
+		m[r] = byte(0x80 + i)
+		i++
+	}
+	return m
+}()
+
+// encodeCP437 converts s for the printer, characters it can't print
+// become '?'.
+func encodeCP437(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch c, ok := cp437Bytes[r]; {
+		case r >= 0x20 && r < 0x7f:
+			b = append(b, byte(r))
+		case ok:
+			b = append(b, c)
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// welcomeSlip lays out the printed greeting for name.
+func welcomeSlip(greeting, name string, t time.Time) []byte {
+	var b bytes.Buffer
+	b.WriteString(escInit + escCenter)
+	b.Write(encodeCP437(greeting))
+	b.WriteString("\n" + escBigText)
+	b.Write(encodeCP437(name))
+	b.WriteString("\n" + escNormalText + "\n")
+	b.WriteString(t.Format("Mon 2 Jan 2006 15:04") + "\n")
+	b.WriteString(escFeedCut)
+	return b.Bytes()
+}
+
+// openPrinter connects to a printer given as tcp://host:port, or opens it
+// as a device file such as /dev/usb/lp0.
+func openPrinter(addr string) (printer, error) {
+	if hostport := strings.TrimPrefix(addr, "tcp://"); hostport != addr {
+		return net.DialTimeout("tcp", hostport, printerTimeout)
+	}
+	return os.OpenFile(addr, os.O_WRONLY|os.O_APPEND, 0)
+}
+
+// printSlip prints the welcome slip for name on the printer at addr. The
+// printer is opened for each slip, so one that is switched off for a
+// while only costs the slips printed meanwhile.
+func printSlip(addr, greeting, name string, t time.Time) error {
+	slip := welcomeSlip(greeting, name, t)
+	return withPrinterTimeout(printerTimeout, func() error {
+		p, err := openPrinter(addr)
+		if err != nil {
+			return err
+		}
+		return writeSlip(p, slip, printerTimeout)
+	})
+}
+
+// withPrinterTimeout runs print, giving up on it after timeout. Opening
+// and writing a device file like /dev/usb/lp0 can't be given a deadline,
+// and block for as long as the printer is jammed, so print is left to
+// finish in the background.
+func withPrinterTimeout(timeout time.Duration, print func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- print() }()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return printerTimeoutError(timeout)
+	}
+}
+
+func printerTimeoutError(timeout time.Duration) error {
+	return fmt.Errorf("printer did not take the slip within %v", timeout)
+}
+
+// writeSlip writes slip to p and closes it, giving up after timeout if p
+// can be given a deadline, as network printers can.
+func writeSlip(p printer, slip []byte, timeout time.Duration) error {
+	if err := p.SetDeadline(time.Now().Add(timeout)); err != nil && !errors.Is(err, os.ErrNoDeadline) {
+		p.Close()
+		return err
+	}
+	if _, err := p.Write(slip); err != nil {
+		p.Close()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return printerTimeoutError(timeout)
+		}
+		return err
+	}
+	return p.Close()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeCP437(t *testing.T) {
+	tests := []struct {
+		in  string
+		out []byte
+	}{
+		{in: "Benny", out: []byte("Benny")},
+		{in: "Zoë Ça", out: []byte{'Z', 'o', 0x89, ' ', 0x80, 'a'}},
+		{in: "李\t", out: []byte("??")},
+	}
+
+	for _, tc := range tests {
+		if out := encodeCP437(tc.in); !bytes.Equal(out, tc.out) {
+			t.Errorf("%q: expected % x, got: % x\n", tc.in, tc.out, out)
+		}
+	}
+}
+
+func TestWelcomeSlip(t *testing.T) {
+	slip := welcomeSlip("Nice to meet you", "Zoë", time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC))
+	expected := escInit + escCenter + "Nice to meet you\n" + escBigText + "Zo\x89\n" + escNormalText + "\nFri 1 Mar 2024 09:30\n" + escFeedCut
+	if string(slip) != expected {
+		t.Errorf("expected %q, got: %q\n", expected, slip)
+	}
+}
+
+func TestPrintSlipDevice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lp0")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := printSlip(path, "Nice to meet you", "Benny", time.Now()); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	b, _ := os.ReadFile(path)
+	if !strings.Contains(string(b), "Benny") || !strings.HasSuffix(string(b), escFeedCut) {
+		t.Errorf("expected a slip for Benny, got: %q\n", b)
+	}
+
+	if err := printSlip(filepath.Join(t.TempDir(), "missing"), "Nice to meet you", "Benny", time.Now()); err == nil {
+		t.Error("expected an error for a missing device")
+	}
+}
+
+func TestPrintSlipNetwork(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			got <- nil
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		got <- b
+	}()
+
+	if err := printSlip("tcp://"+ln.Addr().String(), "Nice to meet you", "Benny", time.Now()); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if b := <-got; !strings.Contains(string(b), "Benny") {
+		t.Errorf("expected a slip for Benny, got: %q\n", b)
+	}
+}
+
+func TestWriteSlipTimeout(t *testing.T) {
+	// nobody reads the other end, like a printer that is out of paper
+	conn, other := net.Pipe()
+	defer other.Close()
+	err := writeSlip(conn, welcomeSlip("Nice to meet you", "Benny", time.Now()), 20*time.Millisecond)
+	if expected := "printer did not take the slip within 20ms"; err == nil || err.Error() != expected {
+		t.Errorf("expected error: %v, got: %v\n", expected, err)
+	}
+}
+
+// blockingPrinter is a device file of a jammed printer: it can't be given
+// a deadline and writes block until released.
+type blockingPrinter struct{ release chan struct{} }
+
+func (p blockingPrinter) Write(b []byte) (int, error) {
+	<-p.release
+	return len(b), nil
+}
+
+func (p blockingPrinter) Close() error                  { return nil }
+func (p blockingPrinter) SetDeadline(t time.Time) error { return os.ErrNoDeadline }
+
+func TestPrinterTimeoutBlockingDevice(t *testing.T) {
+	p := blockingPrinter{release: make(chan struct{})}
+	defer close(p.release)
+	slip := welcomeSlip("Nice to meet you", "Benny", time.Now())
+
+	start := time.Now()
+	err := withPrinterTimeout(20*time.Millisecond, func() error {
+		return writeSlip(p, slip, 20*time.Millisecond)
+	})
+	if expected := "printer did not take the slip within 20ms"; err == nil || err.Error() != expected {
+		t.Errorf("expected error: %v, got: %v\n", expected, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected to give up after 20ms, took %v\n", elapsed)
+	}
+
+	// and one that answers in time is waited for
+	if err := withPrinterTimeout(time.Second, func() error { return errors.New("out of paper") }); err == nil || err.Error() != "out of paper" {
+		t.Errorf("expected the printer's error, got: %v\n", err)
+	}
+}
+
+func TestRunDisplayPrinterError(t *testing.T) {
+	c, err := parseDisplayArgs([]string{"--hold", "1ms", "--printer", filepath.Join(t.TempDir(), "missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	n, err := runDisplay(context.Background(), strings.NewReader("Benny\n"), &out, c)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 visitor and nil error, got: %d, %v\n", n, err)
+	}
+	if !strings.Contains(out.String(), "printer: ") {
+		t.Errorf("expected the printer error on screen, got: %q\n", out.String())
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type example struct {
+	topic string
+	args  string
+	usage string
+}
+
+var usageExamples = compiledExamples([]example{
+	{topic: "basics", args: "3", usage: "ask for a name and greet it three times"},
+	{topic: "basics", args: "-n 3", usage: "the same, giving the count as a flag"},
+	{topic: "basics", args: "--lang de 3", usage: "ask and greet in German, the default comes from $LANG or $LC_ALL"},
+	{topic: "basics", args: "--banner --font mini 1", usage: "greet in large ASCII-art letters, in the narrower of the two built-in fonts"},
+	{topic: "basics", args: "--theme bright 3", usage: "greet in brighter colors, set NO_COLOR or give --color never for plain text"},
+	{topic: "basics", args: "--name Benny --forever --interval 5s", usage: "greet Benny every five seconds until stopped with Ctrl+C"},
+	{topic: "filtering", args: "--filter mask 2", usage: "greet twice, masking rude words in the name"},
+	{topic: "filtering", args: "--allowlist guests.txt --fallback 'Welcome, guest!' 1", usage: "only greet people on the guest list"},
+	{topic: "filtering", args: "assets export", usage: "copy the built-in denylist and fonts to assets in the config directory, where edits to them take effect"},
+	{topic: "batch", args: "lint names.txt", usage: "check names.txt for empty lines, control characters, duplicates and lookalike letters before a big run"},
+	{topic: "batch", args: "--stdin-batch 3 < names.txt", usage: "greet every name in names.txt three times"},
+	{topic: "batch", args: "--stdin-batch --input-encoding latin1 1 < roster.csv", usage: "greet names exported by a legacy system, UTF-16 and Latin-1 are otherwise detected"},
+	{topic: "batch", args: "--stdin-batch --filter reject --report run.json 1 < names.txt", usage: "greet a list of names, skipping rude ones, and report how it went"},
+	{topic: "kiosk", args: "--session --stats stats.json 1", usage: "greet visitors until stdin is closed, keeping hourly stats"},
+	{topic: "kiosk", args: "--session --allowlist guests.txt 1", usage: "greet visitors from a guest list, suggesting matches for typos"},
+	{topic: "kiosk", args: "--session --allowlist guests.txt --show-pronunciation 1", usage: "remind the desk how to say each name, from lines like 'Siobhan Byrne | shi-VAWN BURN'"},
+	{topic: "kiosk", args: "--stdin-batch --suppress optout.txt --audit-log audit.jsonl 1", usage: "skip people who opted out, listed by name or sha256:<hash>, and log each skip"},
+	{topic: "kiosk", args: "display --hold 10s", usage: "show each visitor's greeting full screen in large letters for ten seconds"},
+	{topic: "kiosk", args: "display --printer tcp://192.168.1.50:9100", usage: "greet visitors on screen and print them a welcome slip on a network receipt printer"},
+	{topic: "kiosk", args: "badge --name Benny --contact mailto:benny@example.com --out benny.pdf", usage: "write a name badge for Benny with a QR code of the address"},
+	{topic: "scheduling", args: "--at 17:00 1", usage: "greet at five in the afternoon"},
+	{topic: "scheduling", args: "--in 10m 1", usage: "greet in ten minutes"},
+	{topic: "serve", args: "serve --addr :8080", usage: "answer GET /greet?name=Benny&times=3 over HTTP on port 8080"},
+	{topic: "serve", args: "service launchd --load --log /tmp/name-cli.log -- serve --addr :8080", usage: "keep the server running on macOS, started at login and restarted by launchd"},
+	{topic: "serve", args: "serve --idle-timeout 10m", usage: "stop the server after ten minutes without requests, for scale-to-zero platforms"},
+	{topic: "serve", args: "serve --handoff-socket /run/name-cli.sock --upgrade", usage: "start a new build of the server in place of the running one without dropping connections"},
+	{topic: "serve", args: "loadtest --server http://localhost:8080 --rps 100 --duration 60s --ramp 10s", usage: "load a running server for a minute, ramping up over the first ten seconds, and report latency percentiles"},
+	{topic: "scripting", args: "--name Benny 5", usage: "greet Benny five times without prompting"},
+	{topic: "scripting", args: "--input-timeout 30s --default-name Guest 1", usage: "ask for a name but greet Guest if nobody answers within 30 seconds, without it the run fails with exit code 124"},
+	{topic: "scripting", args: "--force-prompt 1", usage: "ask for the name even when it's piped in, which is otherwise read without a prompt"},
+	{topic: "scripting", args: "--default-name Guest 1", usage: "greet Guest when run from cron or a pipe with no name on stdin, instead of the OS user"},
+	{topic: "scripting", args: "--name Benny --interval 500ms 10", usage: "greet ten times, half a second apart, for a demo or a consumer that can't keep up"},
+	{topic: "scripting", args: "--name Benny --template '{{.Iteration}}:{{.Name}}' 3", usage: "number each greeting, using a Go text/template"},
+	{topic: "scripting", args: "--name Benny --lang de --template '{{.Total}}x{{.Time|date}}' 1000", usage: "format numbers and dates in a template for the language, as 1.000 and 7.3.2026"},
+	{topic: "scripting", args: "--name Benny --output json 3", usage: "print one JSON object per greeting, see --schema greeting"},
+	{topic: "scripting", args: "--name Benny -o greetings.txt 10", usage: "write the greetings to greetings.txt, which is only replaced if the run succeeds"},
+	{topic: "scripting", args: "--name Benny -o greetings.txt --output-encoding utf16le 3", usage: "write the greetings as UTF-16 with a byte order mark, for Windows tools that expect it"},
+	{topic: "scripting", args: "--stdin-batch --output-file greetings.txt --append 1", usage: "add to the end of greetings.txt instead"},
+	{topic: "scripting", args: "--report run.json 5", usage: "greet five times and write a JSON report of the run"},
+	{topic: "scripting", args: "history --name Benny --last 5", usage: "list the last five times Benny was greeted"},
+	{topic: "scripting", args: "--show-streak --name Benny 1", usage: "greet Benny in a daily standup bot, cheering on days in a row"},
+	{topic: "scripting", args: "stats streaks", usage: "list the streaks of everyone greeted"},
+	{topic: "scripting", args: "--no-history --name Benny 1", usage: "greet without keeping it in the history"},
+	{topic: "scripting", args: "diff old.ndjson new.ndjson", usage: "check that two runs with --output json greeted the same names as often, after changing a wrapper script"},
+	{topic: "scripting", args: "--explain --allowlist guests.txt --report run.json 1", usage: "show what the run will do, then run it"},
+	{topic: "scripting", args: "--schema report", usage: "print the JSON Schema of the run report"},
+	{topic: "scripting", args: "state size", usage: "show how much space the config and history take up"},
+	{topic: "scripting", args: "completion bash > /etc/bash_completion.d/name-cli", usage: "install tab completion for bash"},
+	{topic: "scripting", args: "completion zsh > ~/.zfunc/_name-cli", usage: "install tab completion for zsh, with ~/.zfunc in $fpath"},
+	{topic: "scripting", args: "completion fish > ~/.config/fish/completions/name-cli.fish", usage: "install tab completion for fish"},
+	{topic: "scripting", args: "internal completions-dir bash", usage: "print where a package's post-install hook should put the bash completion, next to the binary's bin directory"},
+	{topic: "scripting", args: "--version --short", usage: "print only the version number, to check what got installed"},
+	{topic: "scripting", args: "features", usage: "check whether this build has serve and badge, minimal builds leave them out with -tags \"noserve nobadge\""},
+})
+
+func exampleTopics() []string {
+	topics := []string{}
+	seen := map[string]bool{}
+	for _, e := range usageExamples {
+		if !seen[e.topic] {
+			seen[e.topic] = true
+			topics = append(topics, e.topic)
+		}
+	}
+	return topics
+}
+
+// printExamples writes the examples for topic, or all of them if topic is
+// empty, as a snippet that can be pasted into a shell.
+func printExamples(w io.Writer, prog, topic string) error {
+	found := false
+	for _, e := range usageExamples {
+		if topic != "" && e.topic != topic {
+			continue
+		}
+		if found {
+			fmt.Fprintln(w)
+		}
+		found = true
+		fmt.Fprintf(w, "# %s\n%s %s\n", e.usage, prog, e.args)
+	}
+	if !found {
+		return fmt.Errorf("unknown topic %q, expected one of: %s", topic, strings.Join(exampleTopics(), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintExamples(t *testing.T) {
+	byteBuf := new(bytes.Buffer)
+	if err := printExamples(byteBuf, "name-cli", ""); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if got := strings.Count(byteBuf.String(), "\nname-cli "); got != len(usageExamples) {
+		t.Errorf("expected all %v examples, got: %v\n", len(usageExamples), got)
+	}
+
+	byteBuf.Reset()
+	if err := printExamples(byteBuf, "name-cli", "scheduling"); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	expected := "# greet at five in the afternoon\nname-cli --at 17:00 1\n\n# greet in ten minutes\nname-cli --in 10m 1\n"
+	if byteBuf.String() != expected {
+		t.Errorf("expected examples to be: %q, got: %q\n", expected, byteBuf.String())
+	}
+
+	err := printExamples(new(bytes.Buffer), "name-cli", "juggling")
+	expectedErr := `unknown topic "juggling", expected one of: basics, filtering, batch, kiosk, scheduling, serve, scripting`
+	if !hasServe {
+		expectedErr = strings.Replace(expectedErr, " serve,", "", 1)
+	}
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error: %v, got: %v\n", expectedErr, err)
+	}
+}
+
+// TestExamplesParse makes sure the examples we advertise are accepted by
+// parseArgs and validateArgs.
+func TestExamplesParse(t *testing.T) {
+	for _, e := range usageExamples {
+		args := strings.Fields(strings.ReplaceAll(e.args, "'Welcome, guest!'", "Welcome"))
+		// drop shell redirections, they aren't seen by the tool
+		for i, arg := range args {
+			if arg == "<" || arg == ">" {
+				args = args[:i]
+				break
+			}
+		}
+		c, err := parseArgs(args)
+		if err != nil {
+			t.Errorf("expected example %q to parse, got: %v\n", e.args, err)
+			continue
+		}
+		if err := validateArgs(c); err != nil {
+			t.Errorf("expected example %q to be valid, got: %v\n", e.args, err)
+		}
+	}
+}
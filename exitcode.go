@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Exit statuses of a failed run, so scripts can tell a mistake on the
+// command line from a name that wasn't given or a file that couldn't be
+// written. Interrupted runs and input timeouts have their own, see
+// exitInterrupted and exitInputTimeout.
+const (
+	exitFailure = 1 // anything not covered below
+	exitUsage   = 2 // bad arguments or options
+	exitInput   = 3 // no name was entered, or one that may not be greeted
+	exitIO      = 4 // a file or the output couldn't be read or written
+)
+
+// usageError marks an error in the arguments or options, from parseArgs
+// or validateArgs.
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// inputError marks a name that may not be greeted, like one on the
+// denylist or not on the allowlist.
+type inputError struct{ err error }
+
+func (e inputError) Error() string { return e.err.Error() }
+func (e inputError) Unwrap() error { return e.err }
+
+// ioError marks a failure to write the output that isn't a file system
+// error of its own, like the one injected by --fail-after.
+type ioError struct{ err error }
+
+func (e ioError) Error() string { return e.err.Error() }
+func (e ioError) Unwrap() error { return e.err }
+
+// exitCode is the exit status for err, interrupted if the run was stopped
+// by a signal.
+func exitCode(err error, interrupted bool) int {
+	var (
+		usage   usageError
+		input   inputError
+		ioErr   ioError
+		pathErr *fs.PathError
+	)
+	switch {
+	case interrupted || errors.Is(err, errInterrupted):
+		return exitInterrupted
+	case errors.Is(err, errInputTimeout):
+		return exitInputTimeout
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, ErrEmptyName) || errors.As(err, &input):
+		return exitInput
+	case errors.As(err, &pathErr) || errors.As(err, &ioErr):
+		return exitIO
+	}
+	return exitFailure
+}
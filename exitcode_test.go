@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	_, parseErr := parseArgs([]string{"1", "foo"})
+	_, openErr := os.Open("does-not-exist.txt")
+
+	tests := []struct {
+		err         error
+		interrupted bool
+		code        int
+	}{
+		{err: errors.New("boom"), code: exitFailure},
+		{err: usageError{parseErr}, code: exitUsage},
+		{err: usageError{ErrEmptyName}, code: exitUsage},
+		{err: ErrEmptyName, code: exitInput},
+		{err: notAllowedError(config{}), code: exitInput},
+		{err: fmt.Errorf("line 2: %w", inputError{errors.New("that name is not allowed")}), code: exitInput},
+		{err: fmt.Errorf("could not read denylist: %w", openErr), code: exitIO},
+		{err: (&chaosWriter{failAfter: 1}).injectedError(), code: exitIO},
+		{err: batchError{greeted: 1, failed: 1, first: fmt.Errorf("line 2: %w", notAllowedError(config{}))}, code: exitInput},
+		{err: errInputTimeout, code: exitInputTimeout},
+		{err: errInterrupted, code: exitInterrupted},
+		{err: errors.New("boom"), interrupted: true, code: exitInterrupted},
+	}
+
+	for _, tc := range tests {
+		if code := exitCode(tc.err, tc.interrupted); code != tc.code {
+			t.Errorf("%v: expected exit code %d, got: %d\n", tc.err, tc.code, code)
+		}
+	}
+}
+
+func TestExitCodeRun(t *testing.T) {
+	tests := []struct {
+		args  []string
+		input string
+		code  int
+	}{
+		{args: []string{"--name", "Benny", "--fail-after", "1", "3"}, code: exitIO},
+		{args: []string{"--stdin-batch", "--filter", "reject", "1"}, input: "Ann\nfuck\nBob\n", code: exitInput},
+	}
+
+	for _, tc := range tests {
+		cmd := exec.Command("./"+binaryName, tc.args...)
+		cmd.Stdin = strings.NewReader(tc.input)
+		cmd.Run()
+		if code := cmd.ProcessState.ExitCode(); code != tc.code {
+			t.Errorf("%v: expected exit code %d, got: %d\n", tc.args, tc.code, code)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	originFlag    = "flag"
+	originDefault = "default"
+)
+
+// explainNameLen is the name length the output estimate is given for.
+const explainNameLen = 10
+
+func (c *config) setOrigin(name, origin string) {
+	if c.origin == nil {
+		c.origin = map[string]string{}
+	}
+	c.origin[name] = origin
+}
+
+// resolvedOption is the value an option ended up with and where it came
+// from, a flag, the environment, the config file or the default.
+type resolvedOption struct {
+	name   string
+	value  string
+	origin string
+}
+
+// resolvedOptions are the options of a greeting run as resolved into c,
+// in flag order, leaving out aliases and those that only exit.
+func resolvedOptions(c config) []resolvedOption {
+	var opts []resolvedOption
+	// a FlagSet bound to a copy of c reports the resolved values
+	resolved := c
+	newFlagSet(&resolved).VisitAll(func(f *flag.Flag) {
+		if _, ok := flagAliases[f.Name]; ok || exitFlags[f.Name] || f.Name == "explain" {
+			return
+		}
+		origin := c.origin[f.Name]
+		if origin == "" {
+			origin = originDefault
+		}
+		opts = append(opts, resolvedOption{name: f.Name, value: f.Value.String(), origin: origin})
+	})
+	return opts
+}
+
+// explain prints the resolved configuration, where each value came from
+// and the steps the run will go through, for --explain.
+func explain(w io.Writer, c config) {
+	fmt.Fprintln(w, "Configuration:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, o := range resolvedOptions(c) {
+		value := o.value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(tw, "  --%s\t%s\t(%s)\n", o.name, value, o.origin)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nPipeline:")
+	for i, step := range explainPipeline(c) {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, step)
+	}
+
+	var sample bytes.Buffer
+	sc := c
+	sc.numTimes, sc.forever = 1, false
+	greetUser(context.Background(), sc, strings.Repeat("x", explainNameLen), &sample)
+	perName := sample.Len()
+	per := "run"
+	if c.session {
+		per = "visitor"
+	} else if c.stdinBatch {
+		per = "name"
+	}
+	if c.forever {
+		fmt.Fprintf(w, "\nEstimated output: a greeting every %s until interrupted, %d bytes each for a %d character name\n\n",
+			c.interval, perName, explainNameLen)
+		return
+	}
+	fmt.Fprintf(w, "\nEstimated output: %d greetings per %s, %d bytes for a %d character name",
+		c.numTimes, per, c.numTimes*perName, explainNameLen)
+	if c.interval > 0 && c.numTimes > 1 {
+		fmt.Fprintf(w, ", taking %s", time.Duration(c.numTimes-1)*c.interval)
+	}
+	fmt.Fprint(w, "\n\n")
+}
+
+func explainPipeline(c config) []string {
+	steps := []string{}
+	switch {
+	case c.name != "":
+		steps = append(steps, fmt.Sprintf("source: the name %q given with --name", c.name))
+	case c.session:
+		steps = append(steps, "source: read one name per visitor from stdin until it is closed")
+	case c.stdinBatch:
+		steps = append(steps, "source: read one name per line from stdin, skipping blank lines")
+	case c.inputTimeout > 0 && c.defaultName != "":
+		steps = append(steps, fmt.Sprintf("source: read one name from stdin, greeting %q if none comes within %s", c.defaultName, c.inputTimeout))
+	case c.inputTimeout > 0:
+		steps = append(steps, fmt.Sprintf("source: read one name from stdin, failing if none comes within %s", c.inputTimeout))
+	case c.defaultName != "":
+		steps = append(steps, fmt.Sprintf("source: read one name from stdin, greeting %q if stdin isn't a terminal and has none", c.defaultName))
+	default:
+		steps = append(steps, "source: read one name from stdin")
+	}
+
+	if c.suppressFile != "" {
+		step := "transform: silently skip names listed in " + c.suppressFile
+		if c.auditFile != "" {
+			step += ", logging each skip to " + c.auditFile
+		}
+		steps = append(steps, step)
+	}
+	if c.filterMode != "" {
+		lists := "built-in denylist"
+		if c.denylistFile != "" {
+			lists += " and " + c.denylistFile
+		}
+		steps = append(steps, fmt.Sprintf("transform: %s names found in the %s", c.filterMode, lists))
+	}
+	if c.allowlistFile != "" {
+		matchMin := c.matchMin
+		if matchMin == 0 {
+			matchMin = defaultMatchMin
+		}
+		fallback := c.fallbackMsg
+		if fallback == "" {
+			fallback = defaultFallbackMsg
+		}
+		steps = append(steps, fmt.Sprintf("transform: only allow names in %s, suggesting entries at least %s similar, otherwise reply %q",
+			c.allowlistFile, formatFloat(matchMin), fallback))
+	}
+
+	switch {
+	case !c.at.IsZero():
+		steps = append(steps, "wait: until "+formatClock(c.at))
+	case c.in != 0:
+		steps = append(steps, "wait: for "+c.in.String())
+	}
+
+	if c.forever {
+		steps = append(steps, fmt.Sprintf("sink: stdout, a greeting every %s until interrupted", c.interval))
+	} else {
+		step := fmt.Sprintf("sink: stdout, %d %s", c.numTimes, plural(c.numTimes, "greeting"))
+		if c.interval > 0 && c.numTimes > 1 {
+			step += " " + c.interval.String() + " apart"
+		}
+		steps = append(steps, step)
+	}
+	if c.statsFile != "" {
+		steps = append(steps, "sink: session stats to "+c.statsFile+" after every visitor")
+	}
+	if c.reportFile != "" {
+		steps = append(steps, "sink: run report to "+c.reportFile+" when done")
+	}
+	return steps
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// explainLines squashes the tabwriter padding so single lines of the
+// explain output can be compared.
+func explainLines(s string) map[string]bool {
+	lines := map[string]bool{}
+	for _, line := range strings.Split(s, "\n") {
+		lines[strings.Join(strings.Fields(line), " ")] = true
+	}
+	return lines
+}
+
+func TestExplain(t *testing.T) {
+	c, err := parseArgs([]string{"--explain", "--denylist", "words.txt", "--allowlist", "guests.txt", "--in", "10m", "--report", "run.json", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byteBuf := new(bytes.Buffer)
+	explain(byteBuf, c)
+	lines := explainLines(byteBuf.String())
+
+	expected := []string{
+		"--times 3 (flag)",
+		"--report run.json (flag)",
+		"--filter reject (implied by --denylist)",
+		"--denylist words.txt (flag)",
+		"--match-threshold 0.8 (default)",
+		"--stats - (default)",
+		"--session false (default)",
+		"1. source: read one name from stdin",
+		"2. transform: reject names found in the built-in denylist and words.txt",
+		`3. transform: only allow names in guests.txt, suggesting entries at least 0.8 similar, otherwise reply "sorry, you are not on the guest list"`,
+		"4. wait: for 10m0s",
+		"5. sink: stdout, 3 greetings",
+		"6. sink: run report to run.json when done",
+		"Estimated output: 3 greetings per run, 84 bytes for a 10 character name",
+	}
+	for _, line := range expected {
+		if !lines[line] {
+			t.Errorf("expected explain output to contain %q, got:\n%v\n", line, byteBuf.String())
+		}
+	}
+}
+
+func TestRunCmdExplain(t *testing.T) {
+	byteBuf := new(bytes.Buffer)
+	err := runCmd(context.Background(), strings.NewReader("Benny"), byteBuf, config{numTimes: 1, explain: true})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if !strings.HasPrefix(byteBuf.String(), "Configuration:\n") {
+		t.Errorf("expected the plan to be printed first, got: %v\n", byteBuf.String())
+	}
+	if !strings.HasSuffix(byteBuf.String(), "Your name please? Press the return key when done.\nNice to meet you Benny\n") {
+		t.Errorf("expected the greeting to run after the plan, got: %v\n", byteBuf.String())
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// feature is an optional part of the tool. The full build has all of
+// them, a build tag leaves one out to keep minimal installs small, e.g.
+// go build -tags "noserve nobadge".
+type feature struct {
+	name     string
+	tag      string // leaves the feature out
+	summary  string
+	compiled bool
+}
+
+var features = []feature{
+	{name: "serve", tag: "noserve", summary: "the serve and loadtest commands, greetings over HTTP", compiled: hasServe},
+	{name: "badge", tag: "nobadge", summary: "the badge command, PDF name badges with QR codes", compiled: hasBadge},
+}
+
+func findFeature(name string) (feature, bool) {
+	for _, f := range features {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return feature{}, false
+}
+
+// featureCompiled reports whether the feature called name is in this
+// build, or true for "" which is what commands that are always there have.
+func featureCompiled(name string) bool {
+	f, ok := findFeature(name)
+	return name == "" || ok && f.compiled
+}
+
+// errNotCompiled is what running a command of a left out feature gives.
+func errNotCompiled(cmd command) error {
+	f, _ := findFeature(cmd.feature)
+	return fmt.Errorf("%s is not in this build, it was built with -tags %s", cmd.name, f.tag)
+}
+
+// compiledCommands drops the commands of features left out of the build.
+func compiledCommands(cmds []command) []command {
+	compiled := []command{}
+	for _, cmd := range cmds {
+		if featureCompiled(cmd.feature) {
+			compiled = append(compiled, cmd)
+		}
+	}
+	return compiled
+}
+
+// compiledExamples drops the examples running a command that was left out
+// of the build.
+func compiledExamples(examples []example) []example {
+	compiled := []example{}
+	for _, e := range examples {
+		if exampleCompiled(e) {
+			compiled = append(compiled, e)
+		}
+	}
+	return compiled
+}
+
+// exampleCompiled reports whether the command of e, and the one after
+// "--" for the job of a service, are in this build.
+func exampleCompiled(e example) bool {
+	fields := strings.Fields(e.args)
+	names := []string{fields[0]}
+	for i, f := range fields {
+		if f == "--" && i+1 < len(fields) {
+			names = append(names, fields[i+1])
+		}
+	}
+	for _, name := range names {
+		if cmd, ok := findCommandIn(allCommands, name); ok && !featureCompiled(cmd.feature) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseFeaturesArgs(args []string) (config, error) {
+	c := config{command: "features"}
+	rest, err := parseHelpArgs(&c, args)
+	if err != nil {
+		return config{}, err
+	}
+	if len(rest) != 0 && !c.printUsage {
+		return config{}, ErrInvalidArgCount
+	}
+	return c, nil
+}
+
+func printFeatures(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range features {
+		if f.compiled {
+			fmt.Fprintf(tw, "%s\tyes\t%s\n", f.name, f.summary)
+		} else {
+			fmt.Fprintf(tw, "%s\tno\t%s, left out with -tags %s\n", f.name, f.summary, f.tag)
+		}
+	}
+	return tw.Flush()
+}
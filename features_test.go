@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintFeatures(t *testing.T) {
+	var b bytes.Buffer
+	if err := printFeatures(&b); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != len(features) {
+		t.Fatalf("expected a line per feature, got: %q\n", b.String())
+	}
+	for i, f := range features {
+		fields := strings.Fields(lines[i])
+		expected := "yes"
+		if !f.compiled {
+			expected = "no"
+		}
+		if fields[0] != f.name || fields[1] != expected {
+			t.Errorf("expected %s to be listed as %s, got: %q\n", f.name, expected, lines[i])
+		}
+	}
+}
+
+func TestCompiledCommands(t *testing.T) {
+	for _, cmd := range allCommands {
+		_, listed := findCommand(cmd.name)
+		if listed != featureCompiled(cmd.feature) {
+			t.Errorf("%s: expected it to be listed only if its feature %q is compiled in\n", cmd.name, cmd.feature)
+		}
+		if listed {
+			continue
+		}
+		_, err := parseArgs([]string{cmd.name})
+		if err == nil || !strings.HasPrefix(err.Error(), cmd.name+" is not in this build, it was built with -tags no") {
+			t.Errorf("%s: expected an error naming the build tag, got: %v\n", cmd.name, err)
+		}
+	}
+
+	// examples of left out commands are dropped
+	examples := compiledExamples([]example{
+		{topic: "serve", args: "serve --addr :8080"},
+		{topic: "basics", args: "3"},
+		{topic: "serve", args: "service launchd -- serve"},
+	})
+	expected := 1
+	if hasServe {
+		expected = 3
+	}
+	if len(examples) != expected {
+		t.Errorf("expected only examples of compiled commands, got: %+v\n", examples)
+	}
+}
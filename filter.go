@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	filterReject = "reject"
+	filterMask   = "mask"
+)
+
+type denylist map[string]bool
+
+// loadDenylist returns the built-in denylist, or its override from the
+// assets directory, plus the words in path, in encoding enc, if one was
+// given.
+func loadDenylist(path, enc string) (denylist, error) {
+	data, err := readAsset("denylist.txt")
+	if err != nil {
+		return nil, err
+	}
+	d := denylist{}
+	if err := d.read(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return d, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read denylist: %w", err)
+	}
+	defer f.Close()
+	if err := d.read(decodeInput(f, enc)); err != nil {
+		return nil, fmt.Errorf("could not read denylist: %w", err)
+	}
+	return d, nil
+}
+
+func (d denylist) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		d[strings.ToLower(word)] = true
+	}
+	return scanner.Err()
+}
+
+// filter checks every word of name against the denylist. In reject mode a
+// match is an error, in mask mode the offending word is replaced by
+// asterisks.
+func (d denylist) filter(name string, mode string) (string, error) {
+	runes := []rune(name)
+	found := false
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+		if d[strings.ToLower(string(runes[start:i]))] {
+			found = true
+			for j := start; j < i; j++ {
+				runes[j] = '*'
+			}
+		}
+		start = -1
+	}
+
+	if !found {
+		return name, nil
+	}
+	if mode == filterMask {
+		return string(runes), nil
+	}
+	return "", inputError{errors.New("that name is not allowed")}
+}
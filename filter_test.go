@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDenylistFilter(t *testing.T) {
+	d := denylist{"badword": true, "grumpy": true}
+	tests := []struct {
+		name   string
+		mode   string
+		output string
+		err    error
+	}{
+		{name: "Benny Engstrom", mode: filterReject, output: "Benny Engstrom"},
+		{name: "Benny Badword", mode: filterReject, err: errors.New("that name is not allowed")},
+		{name: "Benny BADWORD", mode: filterMask, output: "Benny *******"},
+		{name: "grumpy-badword", mode: filterMask, output: "******-*******"},
+		// only whole words are matched
+		{name: "Grumpyson", mode: filterReject, output: "Grumpyson"},
+	}
+
+	for _, tc := range tests {
+		got, err := d.filter(tc.name, tc.mode)
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("expected error to be: %v, got: %v\n", tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if got != tc.output {
+			t.Errorf("expected filtered name to be: %v, got: %v\n", tc.output, got)
+		}
+	}
+}
+
+func TestLoadDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# comment\n\n  Grumpy \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := loadDenylist(path, "")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if !d["grumpy"] {
+		t.Errorf("expected user supplied word to be in the denylist")
+	}
+	if !d["shit"] {
+		t.Errorf("expected built-in words to be in the denylist")
+	}
+	if d["# comment"] || d[""] {
+		t.Errorf("expected comments and blank lines to be skipped")
+	}
+
+	_, err = loadDenylist(filepath.Join(t.TempDir(), "missing.txt"), "")
+	if err == nil {
+		t.Errorf("expected an error for a missing denylist file")
+	}
+}
+
+// TestBuiltinDenylistNames checks real names aren't caught by the
+// built-in list.
+func TestBuiltinDenylistNames(t *testing.T) {
+	d, err := loadDenylist("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Dick Smith", "Dick Van Dyke"} {
+		if got, err := d.filter(name, filterReject); err != nil || got != name {
+			t.Errorf("expected %v to be allowed, got: %v, %v\n", name, got, err)
+		}
+	}
+	if _, err := d.filter("Benny Dickhead", filterReject); err == nil {
+		t.Error("expected the built-in denylist to still reject dickhead")
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flagAliases maps the short form of a flag to its long name. Both are
+// registered on the FlagSet, the usage text shows them together.
+var flagAliases = map[string]string{
+	"h": "help",
+	"n": "times",
+	"o": "output-file",
+}
+
+// exitFlags make the tool print something and exit instead of greeting.
+var exitFlags = map[string]bool{
+	"help":         true,
+	"usage-format": true,
+	"schema":       true,
+}
+
+// hiddenFlags work like any other flag but are left out of the usage text
+// and completions, as they are only meant for testing.
+var hiddenFlags = map[string]bool{
+	"fail-after":     true,
+	"slow-writes":    true,
+	"corrupt-output": true,
+}
+
+func defaultConfig() config {
+	return config{
+		fallbackMsg:   defaultFallbackMsg,
+		matchMin:      defaultMatchMin,
+		template:      defaultTemplate,
+		output:        outputText,
+		color:         colorAuto,
+		font:          defaultFont,
+		inputEncoding: encodingAuto,
+		theme:         defaultTheme,
+		maxRetries:    defaultMaxRetries,
+	}
+}
+
+// newFlagSet registers every flag the tool accepts, bound to the fields of
+// c. The current values of c are used as the flag defaults, so a FlagSet
+// for a parsed config reports the parsed values and one for
+// defaultConfig() reports the defaults.
+func newFlagSet(c *config) *flag.FlagSet {
+	fs := flag.NewFlagSet("name-cli", flag.ContinueOnError)
+	// parse errors are returned and printed by main, not by the FlagSet
+	fs.SetOutput(io.Discard)
+
+	fs.BoolVar(&c.printUsage, "help", c.printUsage, "show this help and exit")
+	fs.BoolVar(&c.printUsage, "h", c.printUsage, "show this help and exit")
+	fs.Var(&funcValue{
+		get: func() string {
+			if c.usageFormat == "" {
+				return usageText
+			}
+			return c.usageFormat
+		},
+		set: func(v string) error {
+			c.printUsage = true
+			c.usageFormat = v
+			return nil
+		},
+	}, "usage-format", "print this help as `text|markdown|man` and exit")
+	fs.StringVar(&c.printSchema, "schema", c.printSchema, "print the JSON Schema for `name` and exit, one of: "+strings.Join(schemaNames(), ", "))
+
+	fs.StringVar(&c.configFile, "config", c.configFile, "read default options from `file` instead of config.yaml or config.toml in the config directory state path shows")
+	fs.IntVar(&c.numTimes, "times", c.numTimes, "number of times to greet, instead of giving the `count` as an argument")
+	fs.IntVar(&c.numTimes, "n", c.numTimes, "number of times to greet, instead of giving the `count` as an argument")
+	fs.BoolVar(&c.forever, "forever", c.forever, "keep greeting until interrupted with Ctrl+C, instead of a count")
+	fs.DurationVar(&c.interval, "interval", c.interval, "wait `duration` between greetings, 1s if not given with --forever")
+	fs.StringVar(&c.name, "name", c.name, "greet `name` without asking for it, for use in scripts")
+	fs.BoolVar(&c.noPrompt, "no-prompt", c.noPrompt, "read the name without asking for it, the default when stdin isn't a terminal")
+	fs.BoolVar(&c.forcePrompt, "force-prompt", c.forcePrompt, "ask for the name even when stdin isn't a terminal")
+	fs.IntVar(&c.maxRetries, "max-retries", c.maxRetries, "ask for the name up to `count` more times if it is left empty")
+	fs.DurationVar(&c.inputTimeout, "input-timeout", c.inputTimeout, "stop waiting for the name after `duration`, greeting --default-name if set")
+	fs.StringVar(&c.defaultName, "default-name", c.defaultName, "`name` to greet if none is entered within --input-timeout or piped in, instead of the OS user name")
+	fs.StringVar(&c.template, "template", c.template, "greeting `template` in Go text/template syntax, with .Name, .Iteration and .Total, which print in the number format of --lang, and .Time, which date formats for it")
+	fs.BoolVar(&c.banner, "banner", c.banner, "greet in large letters drawn with ASCII characters")
+	fs.StringVar(&c.font, "font", c.font, "`font` of --banner, one of: "+strings.Join(fontNames(), ", "))
+	fs.StringVar(&c.color, "color", c.color, "`when` to color the prompt and greeting: auto, only on a terminal and without $NO_COLOR, always or never")
+	fs.StringVar(&c.theme, "theme", c.theme, "colors to use, one of: "+strings.Join(themeNames(), ", "))
+	fs.StringVar(&c.lang, "lang", c.lang, "`language` of the prompt, greeting and help, instead of the one from $LANG ("+strings.Join(langNames(), ", ")+")")
+	fs.StringVar(&c.output, "output", c.output, "print the greetings as `text|json`, json being one object per line with the name, greeting and index")
+	fs.StringVar(&c.outputFile, "output-file", c.outputFile, "write the greetings to `file` instead of stdout, replacing it only if the run succeeds")
+	fs.StringVar(&c.outputFile, "o", c.outputFile, "write the greetings to `file` instead of stdout, replacing it only if the run succeeds")
+	fs.BoolVar(&c.appendOutput, "append", c.appendOutput, "add to the end of the --output-file instead of replacing it")
+	fs.StringVar(&c.outEncoding, "output-encoding", c.outEncoding, "`encoding` of the --output-file, for tools that can't read UTF-8, one of: "+strings.Join(outputEncodings, ", "))
+	fs.StringVar(&c.outputBOM, "output-bom", c.outputBOM, "`when` to start the --output-file with a byte order mark: auto, only for UTF-16, always or never")
+	fs.BoolVar(&c.explain, "explain", c.explain, "print the resolved configuration and what the run will do before running")
+	fs.StringVar(&c.reportFile, "report", c.reportFile, "write a machine-readable report of the run to `file.json`")
+	fs.BoolVar(&c.noHistory, "no-history", c.noHistory, "don't add the greeting to the history, which the history command lists")
+	addNameCheckFlags(fs, c)
+	fs.BoolVar(&c.pronounce, "show-pronunciation", c.pronounce, "print how the name is pronounced, from the allowlist, after the greeting")
+	fs.BoolVar(&c.showStreak, "show-streak", c.showStreak, "print how many days in a row the name was greeted, and milestones like the 10th greeting, after the greeting")
+	addSuppressFlags(fs, c)
+	fs.BoolVar(&c.session, "session", c.session, "keep greeting visitors one after another until the input is closed")
+	fs.BoolVar(&c.stdinBatch, "stdin-batch", c.stdinBatch, "read one name per line from stdin and greet each of them, without prompting")
+	fs.StringVar(&c.statsFile, "stats", c.statsFile, "write per-hour visitor counts of a session to `file.json`")
+	fs.Var(&funcValue{
+		get: func() string {
+			if c.at.IsZero() {
+				return ""
+			}
+			return c.at.Format("15:04:05")
+		},
+		set: func(v string) (err error) {
+			c.at, err = parseClock(v)
+			return err
+		},
+	}, "at", "wait until the given time of day (`HH:MM`) before greeting")
+	fs.DurationVar(&c.in, "in", c.in, "wait for the given `duration` (e.g. 10m, 1h30m) before greeting")
+
+	fs.IntVar(&c.failAfter, "fail-after", c.failAfter, "make writing the output fail after `count` lines")
+	fs.DurationVar(&c.slowWrites, "slow-writes", c.slowWrites, "sleep for `duration` before every write of the output")
+	fs.BoolVar(&c.corrupt, "corrupt-output", c.corrupt, "garble every tenth byte of the output")
+
+	return fs
+}
+
+// addNameCheckFlags registers the flags that decide which names get
+// greeted, shared by the greeting and display modes.
+func addNameCheckFlags(fs *flag.FlagSet, c *config) {
+	fs.StringVar(&c.filterMode, "filter", c.filterMode, "what to do with names found in the built-in denylist, `reject|mask`")
+	fs.StringVar(&c.denylistFile, "denylist", c.denylistFile, "extra denied words, one per line in `file` (implies --filter reject)")
+	fs.StringVar(&c.allowlistFile, "allowlist", c.allowlistFile, "only greet names listed in `file`, one per line")
+	fs.StringVar(&c.fallbackMsg, "fallback", c.fallbackMsg, "`message` shown instead of the greeting for names not on the allowlist")
+	fs.Float64Var(&c.matchMin, "match-threshold", c.matchMin, "how close (`0-1`) a name must be to an allowlist entry to be suggested")
+	fs.StringVar(&c.inputEncoding, "input-encoding", c.inputEncoding, "`encoding` of piped names and of the lists above, detected if auto, one of: "+strings.Join(inputEncodings, ", "))
+}
+
+// addSuppressFlags registers the opt-out flags of the batch and kiosk
+// modes.
+func addSuppressFlags(fs *flag.FlagSet, c *config) {
+	fs.StringVar(&c.suppressFile, "suppress", c.suppressFile, "silently skip names or sha256:<hash> entries listed in `file`, with an optional | reason")
+	fs.StringVar(&c.auditFile, "audit-log", c.auditFile, "append a line to `file.jsonl` for every name skipped by --suppress")
+}
+
+// funcValue adapts a pair of functions to flag.Value, for flags whose
+// value needs converting or has side effects.
+type funcValue struct {
+	get func() string
+	set func(string) error
+}
+
+func (f *funcValue) String() string {
+	if f.get == nil {
+		return ""
+	}
+	return f.get()
+}
+
+func (f *funcValue) Set(v string) error { return f.set(v) }
+
+// isZeroDefault reports whether a flag default is the zero value of its
+// type and so not worth showing in the usage text.
+func isZeroDefault(f *flag.Flag) bool {
+	switch f.DefValue {
+	case "", "0", "false", "0s":
+		return true
+	}
+	return false
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func formatClock(t time.Time) string {
+	return t.Format("15:04:05")
+}
@@ -0,0 +1,44 @@
+package main
+
+// editDistance is the Levenshtein distance between a and b, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// similarity turns the edit distance into a score between 0 (nothing in
+// common) and 1 (identical).
+func similarity(a, b string) float64 {
+	longest := len([]rune(a))
+	if n := len([]rune(b)); n > longest {
+		longest = n
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(a, b))/float64(longest)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
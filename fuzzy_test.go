@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{a: "", b: "", distance: 0},
+		{a: "benny", b: "", distance: 5},
+		{a: "benny", b: "benny", distance: 0},
+		{a: "beny", b: "benny", distance: 1},
+		{a: "kitten", b: "sitting", distance: 3},
+		{a: "zoë", b: "zoe", distance: 1},
+	}
+
+	for _, tc := range tests {
+		if got := editDistance(tc.a, tc.b); got != tc.distance {
+			t.Errorf("expected distance between %q and %q to be: %v, got: %v\n", tc.a, tc.b, tc.distance, got)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		score float64
+	}{
+		{a: "", b: "", score: 1},
+		{a: "beny", b: "benny", score: 0.8},
+		{a: "abc", b: "xyz", score: 0},
+	}
+
+	for _, tc := range tests {
+		if got := similarity(tc.a, tc.b); math.Abs(got-tc.score) > 1e-9 {
+			t.Errorf("expected similarity of %q and %q to be: %v, got: %v\n", tc.a, tc.b, tc.score, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+//go:build !noserve
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+)
+
+// A running serve with --handoff-socket gives its listening socket to a
+// new process started with --upgrade on the same path: the new process
+// connects, receives the socket, says it's ready and the old one stops
+// accepting and finishes its requests. Connections arriving meanwhile
+// wait in the socket's backlog for whichever process accepts first, so
+// none are dropped.
+
+const handoffReady = "ready\n"
+
+var errHandoffUnsupported = errors.New("socket handoff is not supported on this platform")
+
+// handoffServer listens on the handoff socket of a running server.
+type handoffServer struct {
+	ctl  *net.UnixListener
+	done chan struct{} // closed once the listener was handed over
+	once sync.Once
+}
+
+// listenHandoff starts listening for an upgrade on path, handing ln over
+// to the first process that asks. A socket file left behind by a server
+// that is gone is removed.
+func listenHandoff(path string, ln net.Listener) (*handoffServer, error) {
+	if fi, err := os.Stat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a server is already using %s, start the new one with --upgrade", path)
+		}
+		os.Remove(path)
+	}
+	ctl, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on handoff socket: %w", err)
+	}
+	h := &handoffServer{ctl: ctl, done: make(chan struct{})}
+	go h.serve(ln)
+	return h, nil
+}
+
+func (h *handoffServer) serve(ln net.Listener) {
+	for {
+		conn, err := h.ctl.AcceptUnix()
+		if err != nil {
+			return
+		}
+		if h.handOver(conn, ln) {
+			return
+		}
+	}
+}
+
+// handOver sends ln to the process on conn and reports whether it took
+// it. The handoff socket is closed before conn, so the new process can
+// listen on the path once it sees conn closed.
+func (h *handoffServer) handOver(conn *net.UnixConn, ln net.Listener) bool {
+	defer conn.Close()
+	if err := sendListener(conn, ln); err != nil {
+		return false
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != handoffReady {
+		return false
+	}
+	h.Close()
+	h.once.Do(func() { close(h.done) })
+	return true
+}
+
+// Close stops listening for upgrades and removes the socket file.
+func (h *handoffServer) Close() error {
+	return h.ctl.Close()
+}
+
+// takeListener asks the server on the handoff socket at path for its
+// listening socket. It returns once the old server has let go of path.
+func takeListener(path string) (net.Listener, error) {
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("could not reach the running server: %w", err)
+	}
+	defer conn.Close()
+	ln, err := receiveListener(conn)
+	if err != nil {
+		return nil, fmt.Errorf("could not take over the listener: %w", err)
+	}
+	if _, err := io.WriteString(conn, handoffReady); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("could not take over the listener: %w", err)
+	}
+	// the old server closes the connection once it's done with path
+	io.Copy(io.Discard, conn)
+	return ln, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !noserve
+
+package main
+
+import "net"
+
+func sendListener(conn *net.UnixConn, ln net.Listener) error {
+	return errHandoffUnsupported
+}
+
+func receiveListener(conn *net.UnixConn) (net.Listener, error) {
+	return nil, errHandoffUnsupported
+}
@@ -0,0 +1,53 @@
+//go:build (linux || darwin) && !noserve
+
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// sendListener passes the file descriptor of ln over conn.
+func sendListener(conn *net.UnixConn, ln net.Listener) error {
+	fl, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return errors.New("the listener has no file descriptor to hand over")
+	}
+	f, err := fl.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, _, err = conn.WriteMsgUnix([]byte{'L'}, syscall.UnixRights(int(f.Fd())), nil)
+	return err
+}
+
+// receiveListener reads a listener passed by sendListener.
+func receiveListener(conn *net.UnixConn) (net.Listener, error) {
+	buf := make([]byte, 1)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) != 1 {
+		return nil, errors.New("no listener was sent")
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(fds) != 1 {
+		return nil, errors.New("no listener was sent")
+	}
+	f := os.NewFile(uintptr(fds[0]), "listener")
+	defer f.Close()
+	// FileListener dups the descriptor
+	return net.FileListener(f)
+}
@@ -0,0 +1,87 @@
+//go:build (linux || darwin) && !noserve
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeHandoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	sock := filepath.Join(t.TempDir(), "handoff.sock")
+
+	out := new(bytes.Buffer)
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(context.Background(), ln, out, config{command: "serve", handoffSocket: sock})
+	}()
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(sock); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	newLn, err := takeListener(sock)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	defer newLn.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got: %v\n", err)
+		}
+	case <-time.After(shutdownTimeout + time.Second):
+		t.Fatal("old server did not shut down after the handoff")
+	}
+	if !strings.HasSuffix(out.String(), "Handed the listener over to the new server\nShutting down\n") {
+		t.Errorf("expected the handoff message, got: %q\n", out.String())
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("expected the old server to remove %v, got: %v\n", sock, err)
+	}
+
+	// the old server is gone but the address still answers
+	go http.Serve(newLn, newServeMux(serveDefaults()))
+	resp, err := http.Get("http://" + addr + "/greet?name=Benny")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "Nice to meet you Benny\n" {
+		t.Errorf("expected the new server to answer, got: %q\n", body)
+	}
+}
+
+func TestListenHandoffInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	sock := filepath.Join(t.TempDir(), "handoff.sock")
+
+	h, err := listenHandoff(sock, ln)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	defer h.Close()
+	if _, err := listenHandoff(sock, ln); err == nil || !strings.Contains(err.Error(), "--upgrade") {
+		t.Errorf("expected an error pointing at --upgrade, got: %v\n", err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// historyEntry is a greeting kept in the history: who was greeted, how
+// many times and when.
+type historyEntry struct {
+	SchemaVersion int       `json:"schema_version"`
+	Time          time.Time `json:"time"`
+	Name          string    `json:"name"`
+	Count         int       `json:"count"`
+}
+
+// historyStore keeps the greetings of every run, unless --no-history is
+// given.
+type historyStore interface {
+	add(e historyEntry) error
+	// entries returns everything recorded, oldest first.
+	entries() ([]historyEntry, error)
+}
+
+// jsonlHistory is a historyStore in a file with a line of JSON per entry,
+// which is only ever appended to.
+type jsonlHistory struct {
+	path string
+}
+
+// historyFileName is the history in userDataDir.
+const historyFileName = "history.jsonl"
+
+// userDataDir is name-cli in the platform's data directory:
+// ~/.local/share, %LocalAppData% on Windows, or $XDG_DATA_HOME if set.
+// macOS has no data directory of its own, there it is the data directory
+// inside userConfigDir. It is "" if there is no home directory.
+func userDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "name-cli")
+	}
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "name-cli")
+		}
+		return ""
+	case "darwin", "ios":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(dir, "name-cli", "data")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", "name-cli")
+}
+
+// openHistory returns the history in the user's data directory, or nil
+// if there is no home directory to keep it in.
+func openHistory() historyStore {
+	dir := userDataDir()
+	if dir == "" {
+		return nil
+	}
+	return jsonlHistory{path: filepath.Join(dir, historyFileName)}
+}
+
+func (h jsonlHistory) add(e historyEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("could not write history: %w", err)
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("could not write history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not write history: %w", err)
+	}
+	return nil
+}
+
+// entries reads the file, which doesn't exist before the first greeting.
+// A line cut short by a crash is skipped rather than losing the rest.
+func (h jsonlHistory) entries() ([]historyEntry, error) {
+	f, err := os.Open(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read history: %w", err)
+	}
+	defer f.Close()
+
+	entries := []historyEntry{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e historyEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || e.Name == "" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read history: %w", err)
+	}
+	return entries, nil
+}
+
+// recordGreeting adds the greeting of name to the history, if it is
+// kept.
+func recordGreeting(c config, name string, count int) error {
+	if c.noHistory || count == 0 {
+		return nil
+	}
+	h := openHistory()
+	if h == nil {
+		return nil
+	}
+	return h.add(historyEntry{SchemaVersion: schemaVersion, Time: time.Now(), Name: name, Count: count})
+}
+
+func newHistoryFlagSet(c *config) *flag.FlagSet {
+	fs := newHelpFlagSet(c)
+	fs.StringVar(&c.historyName, "name", c.historyName, "only list the greetings of `name`")
+	fs.IntVar(&c.historyLast, "last", c.historyLast, "only list the last `count` greetings")
+	return fs
+}
+
+func parseHistoryArgs(args []string) (config, error) {
+	c := config{command: "history"}
+	fs := newHistoryFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if fs.NArg() != 0 && !c.printUsage {
+		return config{}, ErrInvalidArgCount
+	}
+	if c.historyLast < 0 {
+		return config{}, ErrNonPositiveCount
+	}
+	return c, nil
+}
+
+func runHistory(w io.Writer, c config) error {
+	h := openHistory()
+	if h == nil {
+		return errors.New("no home directory to keep the history in")
+	}
+	entries, err := h.entries()
+	if err != nil {
+		return err
+	}
+	return listHistory(w, filterHistory(entries, c.historyName, c.historyLast))
+}
+
+// filterHistory keeps the entries for name, compared like the allowlist
+// does, if given, and then the last of them.
+func filterHistory(entries []historyEntry, name string, last int) []historyEntry {
+	if name != "" {
+		kept := []historyEntry{}
+		for _, e := range entries {
+			if normalizeName(e.Name) == normalizeName(name) {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+	if last > 0 && len(entries) > last {
+		entries = entries[len(entries)-last:]
+	}
+	return entries
+}
+
+// listHistory writes a line per entry, oldest first, with the time in
+// the local time zone.
+func listHistory(w io.Writer, entries []historyEntry) error {
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, "No greetings yet.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d %s\n", e.Time.Local().Format("2006-01-02 15:04"), strings.TrimSpace(e.Name), e.Count, plural(e.Count, "time"))
+	}
+	return tw.Flush()
+}
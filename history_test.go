@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHistoryArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		c    config
+		err  error
+	}{
+		{args: []string{}, c: config{command: "history"}},
+		{args: []string{"--name", "Benny", "--last", "5"}, c: config{command: "history", historyName: "Benny", historyLast: 5}},
+		{args: []string{"--last", "-1"}, err: ErrNonPositiveCount},
+		{args: []string{"Benny"}, err: ErrInvalidArgCount},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"history"}, tc.args...))
+		if err != tc.err {
+			t.Errorf("%v: expected error: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if tc.err == nil && !reflect.DeepEqual(c, tc.c) {
+			t.Errorf("%v: expected %+v, got: %+v\n", tc.args, tc.c, c)
+		}
+	}
+}
+
+func TestJSONLHistory(t *testing.T) {
+	h := jsonlHistory{path: filepath.Join(t.TempDir(), "name-cli", "history.jsonl")}
+	entries, err := h.entries()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty history before the first greeting, got: %v, %v\n", entries, err)
+	}
+
+	at := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	added := []historyEntry{
+		{SchemaVersion: schemaVersion, Time: at, Name: "Benny", Count: 3},
+		{SchemaVersion: schemaVersion, Time: at.Add(time.Hour), Name: "Ada", Count: 1},
+	}
+	for _, e := range added {
+		if err := h.add(e); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+	}
+	// a line cut short by a crash
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"schema_version":1,"name":"Gr`)
+	f.Close()
+
+	entries, err = h.entries()
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if len(entries) != len(added) {
+		t.Fatalf("expected %d entries, got: %+v\n", len(added), entries)
+	}
+	for i, e := range entries {
+		if !e.Time.Equal(added[i].Time) || e.Name != added[i].Name || e.Count != added[i].Count {
+			t.Errorf("expected %+v, got: %+v\n", added[i], e)
+		}
+	}
+}
+
+func TestFilterHistory(t *testing.T) {
+	entries := []historyEntry{{Name: "Benny"}, {Name: "Ada"}, {Name: "benny "}, {Name: "Grace"}, {Name: "Benny"}}
+	tests := []struct {
+		name  string
+		last  int
+		names []string
+	}{
+		{names: []string{"Benny", "Ada", "benny ", "Grace", "Benny"}},
+		{last: 2, names: []string{"Grace", "Benny"}},
+		{last: 10, names: []string{"Benny", "Ada", "benny ", "Grace", "Benny"}},
+		{name: "BENNY", names: []string{"Benny", "benny ", "Benny"}},
+		{name: "Benny", last: 1, names: []string{"Benny"}},
+		{name: "Linus", names: []string{}},
+	}
+
+	for _, tc := range tests {
+		names := []string{}
+		for _, e := range filterHistory(entries, tc.name, tc.last) {
+			names = append(names, e.Name)
+		}
+		if !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("%q, last %d: expected %q, got: %q\n", tc.name, tc.last, tc.names, names)
+		}
+	}
+}
+
+func TestListHistory(t *testing.T) {
+	at := time.Date(2026, 10, 15, 9, 30, 0, 0, time.Local)
+	out := new(bytes.Buffer)
+	listHistory(out, []historyEntry{{Time: at, Name: "Benny", Count: 3}, {Time: at.Add(time.Hour), Name: "Ada", Count: 1}})
+	expected := "2026-10-15 09:30  Benny  3 times\n2026-10-15 10:30  Ada    1 time\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s\n", expected, out.String())
+	}
+
+	out.Reset()
+	listHistory(out, nil)
+	if out.String() != "No greetings yet.\n" {
+		t.Errorf("expected a note that there is nothing to list, got: %q\n", out.String())
+	}
+}
+
+func TestGreetingsRecorded(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	run := func(c config) {
+		t.Helper()
+		if _, err := runCommand(context.Background(), strings.NewReader(""), new(bytes.Buffer), withDefaults(c)); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+	}
+	run(config{numTimes: 3, name: "Benny"})
+	run(config{numTimes: 2, name: "Ada", noHistory: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := runCommand(ctx, strings.NewReader(""), new(bytes.Buffer), withDefaults(config{forever: true, interval: 10 * time.Millisecond, name: "Grace"})); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+
+	entries, err := openHistory().entries()
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "Benny" || entries[0].Count != 3 || entries[1].Name != "Grace" || entries[1].Count < 2 {
+		t.Errorf("expected Benny 3 times and Grace until interrupted, got: %+v\n", entries)
+	}
+
+	out := new(bytes.Buffer)
+	if _, err := runCommand(context.Background(), strings.NewReader(""), out, config{command: "history", historyName: "benny"}); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if !strings.HasSuffix(out.String(), "  Benny  3 times\n") || strings.Count(out.String(), "\n") != 1 {
+		t.Errorf("expected Benny's greeting, got: %q\n", out.String())
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jordanengstrom/name-cli-app.git/pkg/greeter"
+)
+
+// messages are the strings shown to people in one language. Flag and
+// command descriptions in the usage text stay in English.
+type messages struct {
+	greeting    string // comes before the name, as in "Nice to meet you Benny"
+	prompt      string
+	didYouMean  string // formatted with the allowlist's suggestion
+	yes         string // the answer that confirms didYouMean, or its initial
+	noName      string
+	interrupted string
+	timedOut    string
+	description string
+	environment string
+	// headings of the usage text, commandsHint and examplesHint are
+	// formatted with the command to run
+	usage        string
+	commands     string
+	commandsHint string
+	options      string
+	examples     string
+	examplesHint string // and with the list of topics
+	// how num and date format in greeting templates
+	thousands  string // between groups of three digits
+	dateLayout string // for time.Format
+}
+
+const defaultLang = "en"
+
+var catalogs = map[string]messages{
+	"en": {
+		greeting:     "Nice to meet you",
+		prompt:       "Your name please? Press the return key when done.",
+		didYouMean:   "Did you mean %s? [y/N]",
+		yes:          "yes",
+		noName:       "you didn't enter your name",
+		interrupted:  "interrupted",
+		timedOut:     "input timed out",
+		description:  usageDescription,
+		environment:  usageEnvironment,
+		usage:        "Usage",
+		commands:     "Commands",
+		commandsHint: "see %s for their options",
+		options:      "Options",
+		examples:     "Examples",
+		examplesHint: "see %s for more, topics: %s",
+		thousands:    ",",
+		dateLayout:   "1/2/2006",
+	},
+	"de": {
+		greeting:     "Schön, dich kennenzulernen,",
+		prompt:       "Wie heißt du? Drücke die Eingabetaste, wenn du fertig bist.",
+		didYouMean:   "Meintest du %s? [j/N]",
+		yes:          "ja",
+		noName:       "du hast keinen Namen eingegeben",
+		interrupted:  "abgebrochen",
+		timedOut:     "Zeitüberschreitung bei der Eingabe",
+		description:  "Ein Begrüßungsprogramm, das den eingegebenen Namen <count> Mal ausgibt.",
+		environment:  "Jede Option kann auch mit einer nach ihr benannten Umgebungsvariable gesetzt werden, etwa NAME_CLI_TIMES für --times, oder in config.yaml im Konfigurationsverzeichnis, das name-cli state path anzeigt. Die Befehlszeile hat Vorrang vor der Umgebung und diese vor der Konfigurationsdatei.",
+		usage:        "Aufruf",
+		commands:     "Befehle",
+		commandsHint: "siehe %s für ihre Optionen",
+		options:      "Optionen",
+		examples:     "Beispiele",
+		examplesHint: "mehr mit %s, Themen: %s",
+		thousands:    ".",
+		dateLayout:   "2.1.2006",
+	},
+	"es": {
+		greeting:     "Mucho gusto,",
+		prompt:       "¿Cómo te llamas? Pulsa la tecla Intro cuando termines.",
+		didYouMean:   "¿Querías decir %s? [s/N]",
+		yes:          "sí",
+		noName:       "no has introducido tu nombre",
+		interrupted:  "interrumpido",
+		timedOut:     "se agotó el tiempo de espera de la entrada",
+		description:  "Un programa de saludo que muestra el nombre introducido <count> veces.",
+		environment:  "Cada opción también se puede definir con una variable de entorno con su nombre, como NAME_CLI_TIMES para --times, o en config.yaml en el directorio de configuración que muestra name-cli state path. La línea de comandos tiene prioridad sobre el entorno, y este sobre el archivo de configuración.",
+		usage:        "Uso",
+		commands:     "Comandos",
+		commandsHint: "consulta %s para ver sus opciones",
+		options:      "Opciones",
+		examples:     "Ejemplos",
+		examplesHint: "más con %s, temas: %s",
+		thousands:    ".",
+		dateLayout:   "2/1/2006",
+	},
+	"fr": {
+		greeting:     "Ravi de vous rencontrer,",
+		prompt:       "Votre nom, s'il vous plaît ? Appuyez sur Entrée pour valider.",
+		didYouMean:   "Vouliez-vous dire %s ? [o/N]",
+		yes:          "oui",
+		noName:       "vous n'avez pas saisi votre nom",
+		interrupted:  "interrompu",
+		timedOut:     "délai de saisie dépassé",
+		description:  "Un programme de salutation qui affiche le nom saisi <count> fois.",
+		environment:  "Chaque option peut aussi être définie par une variable d'environnement portant son nom, comme NAME_CLI_TIMES pour --times, ou dans config.yaml dans le répertoire de configuration qu'affiche name-cli state path. La ligne de commande l'emporte sur l'environnement, qui l'emporte sur le fichier de configuration.",
+		usage:        "Utilisation",
+		commands:     "Commandes",
+		commandsHint: "voir %s pour leurs options",
+		options:      "Options",
+		examples:     "Exemples",
+		examplesHint: "plus avec %s, sujets : %s",
+		thousands:    "\u202f",
+		dateLayout:   "02/01/2006",
+	},
+	"sv": {
+		greeting:     "Trevligt att träffas,",
+		prompt:       "Vad heter du? Tryck på returtangenten när du är klar.",
+		didYouMean:   "Menade du %s? [j/N]",
+		yes:          "ja",
+		noName:       "du angav inget namn",
+		interrupted:  "avbrutet",
+		timedOut:     "tidsgränsen för inmatningen överskreds",
+		description:  "Ett hälsningsprogram som skriver ut namnet du angav <count> gånger.",
+		environment:  "Alla flaggor kan också sättas med en miljövariabel uppkallad efter dem, som NAME_CLI_TIMES för --times, eller i config.yaml i konfigurationskatalogen som name-cli state path visar. Kommandoraden går före miljön, som går före konfigurationsfilen.",
+		usage:        "Användning",
+		commands:     "Kommandon",
+		commandsHint: "se %s för deras flaggor",
+		options:      "Flaggor",
+		examples:     "Exempel",
+		examplesHint: "fler med %s, ämnen: %s",
+		thousands:    "\u00a0",
+		dateLayout:   "2006-01-02",
+	},
+}
+
+func langNames() []string {
+	names := []string{}
+	for name := range catalogs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// baseLang turns a locale such as "de_DE.UTF-8" or "pt-BR" into the
+// language code, "de" or "pt".
+func baseLang(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if i := strings.IndexAny(locale, "_-"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
+
+// langFromEnv is the locale of the environment, from the first of
+// NAME_CLI_LANG, LC_ALL, LC_MESSAGES and LANG that is set. NAME_CLI_LANG
+// is normally applied with the other options, checking it here too makes
+// the help text follow it.
+func langFromEnv() string {
+	for _, name := range []string{envName("lang"), "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// messages returns the catalog for --lang, or for the locale of the
+// environment. Languages without a catalog get English.
+func (c config) messages() messages {
+	lang := c.lang
+	if lang == "" {
+		lang = langFromEnv()
+	}
+	if m, ok := catalogs[baseLang(lang)]; ok {
+		return m
+	}
+	return catalogs[defaultLang]
+}
+
+// validateLang checks a language asked for with --lang. Unknown locales in
+// LANG and friends aren't an error, they just mean English.
+func validateLang(lang string) error {
+	if lang == "" {
+		return nil
+	}
+	if _, ok := catalogs[baseLang(lang)]; !ok {
+		return fmt.Errorf("unknown language %q, expected one of: %s", lang, strings.Join(langNames(), ", "))
+	}
+	return nil
+}
+
+// confirms reports whether answer says yes to didYouMean, in m's language
+// or in English.
+func (m messages) confirms(answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "" {
+		return false
+	}
+	initial, _ := utf8.DecodeRuneInString(m.yes)
+	for _, yes := range []string{"y", "yes", m.yes, string(initial)} {
+		if answer == yes {
+			return true
+		}
+	}
+	return false
+}
+
+// errorText is err as shown to the user, translated if it is one of the
+// errors in the catalog.
+func (m messages) errorText(err error) string {
+	switch {
+	case errors.Is(err, ErrEmptyName):
+		return m.noName
+	case errors.Is(err, errInterrupted):
+		return m.interrupted
+	case errors.Is(err, errInputTimeout):
+		return m.timedOut
+	}
+	return err.Error()
+}
+
+// templateFuncs are the num and date helpers of greeting templates,
+// formatting in the language of m.
+func (m messages) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"num":  greeter.Num(m.formatNumber),
+		"date": func(t time.Time) string { return t.Format(m.dateLayout) },
+	}
+}
+
+// formatNumber writes n with the thousands separator of m, as 1,000 in
+// English and 1.000 in German.
+func (m messages) formatNumber(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(m.thousands)
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jordanengstrom/name-cli-app.git/pkg/greeter"
+)
+
+func TestBaseLang(t *testing.T) {
+	tests := map[string]string{
+		"de_DE.UTF-8":   "de",
+		"pt-BR":         "pt",
+		"sv_SE@euro":    "sv",
+		"FR":            "fr",
+		"es_ES.ISO8859": "es",
+		"C":             "c",
+		"":              "",
+	}
+	for locale, expected := range tests {
+		if got := baseLang(locale); got != expected {
+			t.Errorf("%q: expected %q, got: %q\n", locale, expected, got)
+		}
+	}
+}
+
+func TestConfigMessages(t *testing.T) {
+	tests := []struct {
+		lang     string
+		env      map[string]string
+		greeting string
+	}{
+		{greeting: "Nice to meet you"},
+		{lang: "de", greeting: "Schön, dich kennenzulernen,"},
+		{env: map[string]string{"LANG": "fr_FR.UTF-8"}, greeting: "Ravi de vous rencontrer,"},
+		{env: map[string]string{"LANG": "fr_FR.UTF-8", "LC_ALL": "sv_SE.UTF-8"}, greeting: "Trevligt att träffas,"},
+		{env: map[string]string{"LANG": "fr_FR.UTF-8", "LC_MESSAGES": "es_ES.UTF-8"}, greeting: "Mucho gusto,"},
+		{env: map[string]string{"LC_ALL": "fr_FR.UTF-8", "NAME_CLI_LANG": "de"}, greeting: "Schön, dich kennenzulernen,"},
+		{lang: "es", env: map[string]string{"LANG": "de_DE.UTF-8"}, greeting: "Mucho gusto,"},
+		{env: map[string]string{"LANG": "C.UTF-8"}, greeting: "Nice to meet you"},
+		{env: map[string]string{"LANG": "ja_JP.UTF-8"}, greeting: "Nice to meet you"},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.lang, tc.env), func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			if got := (config{lang: tc.lang}).messages().greeting; got != tc.greeting {
+				t.Errorf("expected greeting %q, got: %q\n", tc.greeting, got)
+			}
+		})
+	}
+}
+
+func TestValidateLang(t *testing.T) {
+	for _, lang := range []string{"", "de", "de_AT", "FR"} {
+		if err := validateLang(lang); err != nil {
+			t.Errorf("%q: expected nil error, got: %v\n", lang, err)
+		}
+	}
+	expected := `unknown language "klingon", expected one of: de, en, es, fr, sv`
+	if err := validateLang("klingon"); err == nil || err.Error() != expected {
+		t.Errorf("expected error: %v, got: %v\n", expected, err)
+	}
+}
+
+// TestCatalogsComplete makes sure no translation is missing a message or
+// breaks the placeholders of the English one.
+func TestCatalogsComplete(t *testing.T) {
+	en := reflect.ValueOf(catalogs[defaultLang])
+	for lang, m := range catalogs {
+		v := reflect.ValueOf(m)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i).Name
+			s := v.Field(i).String()
+			if s == "" {
+				t.Errorf("%v: expected %v to be translated\n", lang, field)
+			}
+			if n, expected := strings.Count(s, "%s"), strings.Count(en.Field(i).String(), "%s"); n != expected {
+				t.Errorf("%v: expected %v to have %v placeholders, got: %v\n", lang, field, expected, n)
+			}
+		}
+	}
+}
+
+func TestLocalizedRun(t *testing.T) {
+	c := config{numTimes: 2, lang: "de"}
+	out := new(bytes.Buffer)
+	if err := runCmd(context.Background(), strings.NewReader("Benny\n"), out, c); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	expected := "Wie heißt du? Drücke die Eingabetaste, wenn du fertig bist.\n" + strings.Repeat("Schön, dich kennenzulernen, Benny\n", 2)
+	if out.String() != expected {
+		t.Errorf("expected stdout message to be: %q, got: %q\n", expected, out.String())
+	}
+
+	if got := c.messages().errorText(ErrEmptyName); got != "du hast keinen Namen eingegeben" {
+		t.Errorf("expected the translated error, got: %q\n", got)
+	}
+	if got := c.messages().errorText(errors.New("boom")); got != "boom" {
+		t.Errorf("expected other errors as they are, got: %q\n", got)
+	}
+
+	usage := renderTextUsage("name-cli", c.messages())
+	for _, line := range []string{
+		"Aufruf: name-cli [options] <count>\n",
+		"        name-cli examples [topic]\n",
+		"Befehle (siehe 'name-cli <command> --help' für ihre Optionen):\n",
+		"Optionen:\n",
+	} {
+		if !strings.Contains(usage, line) {
+			t.Errorf("expected usage to contain the line %q, got:\n%v\n", line, usage)
+		}
+	}
+}
+
+func TestLocalizedTemplate(t *testing.T) {
+	tests := []struct {
+		lang   string
+		output string
+	}{
+		{lang: "en", output: "2/1,000 on 3/7/2026"},
+		{lang: "de", output: "2/1.000 on 7.3.2026"},
+		{lang: "fr", output: "2/1\u202f000 on 07/03/2026"},
+		{lang: "sv", output: "2/1\u00a0000 on 2026-03-07"},
+	}
+
+	for _, tc := range tests {
+		c := config{lang: tc.lang}
+		tmpl, err := parseTemplate("{{num .Index}}/{{num .Count}} on {{date .Time}}", c.messages())
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		if err := tmpl.Execute(out, greeter.Greeting{Iteration: greeter.Number{Value: 2}, Total: greeter.Number{Value: 1000}, Time: time.Date(2026, 3, 7, 9, 0, 0, 0, time.UTC)}); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tc.output {
+			t.Errorf("%s: expected %q, got: %q\n", tc.lang, tc.output, out.String())
+		}
+	}
+
+	// the fields themselves print in the language too
+	out := new(bytes.Buffer)
+	c := withDefaults(config{lang: "de", name: "Benny", numTimes: 1234, template: "{{.Iteration}}/{{.Total}} {{num .Total}}{{if eq .Iteration.Value 1}} first{{end}}"})
+	if err := greetUser(context.Background(), c, "Benny", out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 1234 || lines[0] != "1/1.234 1.234 first" || lines[1233] != "1.234/1.234 1.234" {
+		t.Errorf("expected localized fields, got: %q ... %q\n", lines[0], lines[len(lines)-1])
+	}
+
+	m := catalogs["en"]
+	for n, expected := range map[int]string{0: "0", 999: "999", -1234: "-1,234", 1234567: "1,234,567"} {
+		if got := m.formatNumber(n); got != expected {
+			t.Errorf("%d: expected %q, got: %q\n", n, expected, got)
+		}
+	}
+}
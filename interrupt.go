@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/jordanengstrom/name-cli-app.git/pkg/greeter"
+)
+
+// exitInterrupted is the exit status of a run stopped by SIGINT or
+// SIGTERM, 128 + SIGINT like shells report a process killed by Ctrl+C.
+const exitInterrupted = 130
+
+var errInterrupted = greeter.ErrInterrupted
+
+// contextReader makes reads from r give up with errInterrupted once ctx is
+// done, so a prompt waiting on the terminal doesn't keep an interrupted run
+// alive. A read still blocked in r is left behind, it ends with the
+// process.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if cr.ctx.Err() != nil {
+		return 0, errInterrupted
+	}
+	// read into a buffer of our own, p may be reused once we return
+	buf := make([]byte, len(p))
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := cr.r.Read(buf)
+		done <- readResult{n, err}
+	}()
+	select {
+	case res := <-done:
+		copy(p, buf[:res.n])
+		return res.n, res.err
+	case <-cr.ctx.Done():
+		return 0, errInterrupted
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cancelWriter cancels the run on its first write, as if Ctrl+C came in
+// while the greetings were being written.
+type cancelWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (cw *cancelWriter) Write(p []byte) (int, error) {
+	cw.cancel()
+	return cw.Buffer.Write(p)
+}
+
+func TestGreetUserInterrupted(t *testing.T) {
+	for _, output := range []string{outputText, outputJSON} {
+		ctx, cancel := context.WithCancel(context.Background())
+		out := &cancelWriter{cancel: cancel}
+		c := config{numTimes: 1000000, output: output}
+		err := greetUser(ctx, withDefaults(c), "Benny", out)
+		if err != errInterrupted {
+			t.Fatalf("%s: expected error: %v, got: %v\n", output, errInterrupted, err)
+		}
+		lines := strings.Count(out.String(), "\n")
+		if lines == 0 || lines >= c.numTimes || !strings.HasSuffix(out.String(), "\n") {
+			t.Errorf("%s: expected the run to stop after some whole lines, got %d lines ending in %q\n", output, lines, out.String()[out.Len()-10:])
+		}
+	}
+}
+
+func TestGreetUserForever(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := new(bytes.Buffer)
+	time.AfterFunc(100*time.Millisecond, cancel)
+	c := withDefaults(config{forever: true, interval: 10 * time.Millisecond, template: "{{.Index}}"})
+	if err := greetUser(ctx, c, "Benny", out); err != nil {
+		t.Fatalf("expected the interrupt to end the run cleanly, got: %v\n", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) < 2 || len(lines) > 11 {
+		t.Errorf("expected a greeting every 10ms for 100ms, got: %q\n", out.String())
+	}
+	for i, line := range lines {
+		if line != strconv.Itoa(i+1) {
+			t.Errorf("expected greeting %d, got: %q\n", i+1, line)
+		}
+	}
+}
+
+func TestContextReader(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := contextReader{ctx: ctx, r: pr}
+
+	go pw.Write([]byte("Benny\n"))
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || scanner.Text() != "Benny" {
+		t.Fatalf("expected to read a line, got: %q, %v\n", scanner.Text(), scanner.Err())
+	}
+
+	// nothing more is written, the read only ends with the cancel
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if scanner.Scan() || scanner.Err() != errInterrupted {
+		t.Errorf("expected error: %v, got: %v\n", errInterrupted, scanner.Err())
+	}
+}
+
+func TestInterruptExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("can't send os.Interrupt on windows")
+	}
+	cmd := exec.Command("./"+binaryName, "--name", "Benny", "1000000000")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(stdout)
+	if line, err := r.ReadString('\n'); err != nil || line != "Nice to meet you Benny\n" {
+		t.Fatalf("expected a greeting, got: %q, %v\n", line, err)
+	}
+	cmd.Process.Signal(os.Interrupt)
+	rest, _ := io.ReadAll(r)
+	err = cmd.Wait()
+
+	if cmd.ProcessState.ExitCode() != exitInterrupted {
+		t.Errorf("expected exit code %d, got: %v\n", exitInterrupted, err)
+	}
+	if !strings.HasSuffix(string(rest), "Nice to meet you Benny\ninterrupted\n") {
+		t.Errorf("expected whole greetings and then the interruption, got the end: %q\n", rest[len(rest)-40:])
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// confusableScripts are the scripts whose letters look alike, a word
+// mixing them is more likely a copy and paste accident or a spoof than a
+// name. Scripts that are mixed on purpose, like Han and Hiragana, aren't
+// checked.
+var confusableScripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Greek", unicode.Greek},
+}
+
+func parseLintArgs(args []string) (config, error) {
+	c := config{command: "lint"}
+	rest, err := parseHelpArgs(&c, args)
+	if err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return c, nil
+	}
+	if len(rest) != 1 {
+		return config{}, ErrInvalidArgCount
+	}
+	c.lintFile = rest[0]
+	return c, nil
+}
+
+// runLint checks c.lintFile for lines --stdin-batch would skip or greet
+// oddly and reports them with their line numbers on w.
+func runLint(w io.Writer, c config) error {
+	f, err := os.Open(c.lintFile)
+	if err != nil {
+		return fmt.Errorf("could not read names: %w", err)
+	}
+	defer f.Close()
+
+	issues, err := lintNames(f, w)
+	if err != nil {
+		return fmt.Errorf("could not read names: %w", err)
+	}
+	switch {
+	case issues == 1:
+		return inputError{fmt.Errorf("1 problem in %s", c.lintFile)}
+	case issues > 1:
+		return inputError{fmt.Errorf("%d problems in %s", issues, c.lintFile)}
+	}
+	return nil
+}
+
+// lintNames reads names from r, one per line, and writes a line to w for
+// every empty line, control character, invalid UTF-8, word mixing
+// confusableScripts and repeated name. It returns how many it found.
+func lintNames(r io.Reader, w io.Writer) (int, error) {
+	scanner := bufio.NewScanner(r)
+	seen := map[string]int{}
+	issues, line := 0, 0
+	report := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, "line %d: %s\n", line, fmt.Sprintf(format, args...))
+		issues++
+	}
+	for scanner.Scan() {
+		line++
+		// files written on Windows end their lines with \r\n
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			report("empty line")
+			continue
+		}
+		if !utf8.ValidString(text) {
+			report("invalid UTF-8")
+			continue
+		}
+		for _, r := range text {
+			if unicode.IsControl(r) {
+				report("control character %U", r)
+				break
+			}
+		}
+		for _, word := range strings.Fields(text) {
+			if scripts := wordScripts(word); len(scripts) > 1 {
+				report("%q mixes %s letters", word, strings.Join(scripts, " and "))
+			}
+		}
+		name := normalizeName(text)
+		if first, ok := seen[name]; ok {
+			report("duplicate of line %d", first)
+			continue
+		}
+		seen[name] = line
+	}
+	return issues, scanner.Err()
+}
+
+// wordScripts lists the confusableScripts word has letters of.
+func wordScripts(word string) []string {
+	scripts := []string{}
+	for _, s := range confusableScripts {
+		for _, r := range word {
+			if unicode.Is(s.table, r) {
+				scripts = append(scripts, s.name)
+				break
+			}
+		}
+	}
+	return scripts
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLintArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		c    config
+		err  error
+	}{
+		{args: []string{"names.txt"}, c: config{command: "lint", lintFile: "names.txt"}},
+		{args: []string{"--help"}, c: config{command: "lint", printUsage: true}},
+		{args: []string{}, err: ErrInvalidArgCount},
+		{args: []string{"a.txt", "b.txt"}, err: ErrInvalidArgCount},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"lint"}, tc.args...))
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%v: expected error: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if c.command != tc.c.command || c.lintFile != tc.c.lintFile || c.printUsage != tc.c.printUsage {
+			t.Errorf("%v: expected %+v, got: %+v\n", tc.args, tc.c, c)
+		}
+	}
+}
+
+func TestLintNames(t *testing.T) {
+	input := strings.Join([]string{
+		"Benny",
+		"",
+		"Ada Lovelace\r",
+		"Bell\a",
+		"\xff\xfe",
+		"Bеnny", // a Cyrillic е
+		"ada  lovelace",
+		"Иван Петров",
+		"Γιώργος",
+	}, "\n")
+	expected := strings.Join([]string{
+		"line 2: empty line",
+		"line 4: control character U+0007",
+		"line 5: invalid UTF-8",
+		`line 6: "Bеnny" mixes Latin and Cyrillic letters`,
+		"line 7: duplicate of line 3",
+		"",
+	}, "\n")
+
+	out := new(bytes.Buffer)
+	issues, err := lintNames(strings.NewReader(input), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s\n", expected, out.String())
+	}
+	if issues != 5 {
+		t.Errorf("expected 5 issues, got: %d\n", issues)
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	names := filepath.Join(t.TempDir(), "names.txt")
+	if err := os.WriteFile(names, []byte("Benny\nAda\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err := runLint(out, config{lintFile: names}); err != nil || out.Len() != 0 {
+		t.Errorf("expected a clean file to pass silently, got: %q, %v\n", out.String(), err)
+	}
+
+	if err := os.WriteFile(names, []byte("Benny\nbenny\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := runLint(out, config{lintFile: names})
+	if err == nil || err.Error() != "2 problems in "+names || exitCode(err, false) != exitInput {
+		t.Errorf("expected 2 problems and an input error, got: %v\n", err)
+	}
+
+	err = runLint(out, config{lintFile: filepath.Join(t.TempDir(), "missing.txt")})
+	if exitCode(err, false) != exitIO {
+		t.Errorf("expected an I/O error for a missing file, got: %v\n", err)
+	}
+}
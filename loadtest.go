@@ -0,0 +1,230 @@
+//go:build !noserve
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultServer       = "http://" + defaultListenAddr
+	defaultRPS          = 10
+	defaultLoadDuration = 10 * time.Second
+	// loadRequestTimeout is how long a single request may take before it
+	// counts as failed.
+	loadRequestTimeout = 10 * time.Second
+)
+
+func newLoadtestFlagSet(c *config) *flag.FlagSet {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&c.printUsage, "help", c.printUsage, "show this help and exit")
+	fs.BoolVar(&c.printUsage, "h", c.printUsage, "show this help and exit")
+	fs.StringVar(&c.server, "server", c.server, "base `url` of a running name-cli serve")
+	fs.IntVar(&c.rps, "rps", c.rps, "requests per `second` to send once ramped up")
+	fs.DurationVar(&c.duration, "duration", c.duration, "how long to send requests for")
+	fs.DurationVar(&c.ramp, "ramp", c.ramp, "raise the rate steadily to --rps over this `duration` at the start")
+	return fs
+}
+
+func loadtestDefaults() config {
+	return config{command: "loadtest", server: defaultServer, rps: defaultRPS, duration: defaultLoadDuration}
+}
+
+func parseLoadtestArgs(args []string) (config, error) {
+	c := loadtestDefaults()
+	fs := newLoadtestFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return config{printUsage: true, command: "loadtest"}, nil
+	}
+	if err := resolveEnv(fs, &c); err != nil {
+		return config{}, err
+	}
+	if fs.NArg() != 0 {
+		return config{}, ErrInvalidArgCount
+	}
+	return c, nil
+}
+
+func validateLoadtestArgs(c config) error {
+	u, err := url.Parse(c.server)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("--server must be an http:// or https:// url, got %q", c.server)
+	}
+	if c.rps <= 0 {
+		return errors.New("--rps must be greater than 0")
+	}
+	if c.duration <= 0 {
+		return errors.New("--duration must be greater than 0")
+	}
+	if c.ramp < 0 || c.ramp > c.duration {
+		return errors.New("--ramp must be between 0 and --duration")
+	}
+	return nil
+}
+
+// loadStats collects the outcome of every request of a load test.
+type loadStats struct {
+	mu        sync.Mutex
+	sent      int
+	latencies []time.Duration // of the successful requests
+	failures  map[string]int  // by status code or "error"
+}
+
+func (s *loadStats) record(d time.Duration, failure string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if failure != "" {
+		s.failures[failure]++
+		return
+	}
+	s.latencies = append(s.latencies, d)
+}
+
+func (s *loadStats) failed() int {
+	n := 0
+	for _, count := range s.failures {
+		n += count
+	}
+	return n
+}
+
+// percentile returns the p-th percentile (0-100) of the sorted latencies,
+// by the nearest rank.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// loadSendTime is when the i-th request (from 0) is due, counted from the
+// start of the test. During c.ramp the rate rises in a straight line from
+// nothing to c.rps, so i requests have gone out by the time
+// rps*t^2/(2*ramp) reaches i, after that they go out at c.rps.
+func loadSendTime(c config, i int) time.Duration {
+	rps, ramp := float64(c.rps), c.ramp.Seconds()
+	n := float64(i)
+	rampRequests := rps * ramp / 2
+	if n < rampRequests {
+		return time.Duration(math.Sqrt(2*ramp*n/rps) * float64(time.Second))
+	}
+	return c.ramp + time.Duration((n-rampRequests)/rps*float64(time.Second))
+}
+
+// loadRequest is the URL of the i-th synthetic greeting.
+func loadRequest(server string, i int) string {
+	q := url.Values{}
+	q.Set("name", "Visitor "+strconv.Itoa(i+1))
+	q.Set("times", strconv.Itoa(1+i%3))
+	return strings.TrimSuffix(server, "/") + "/greet?" + q.Encode()
+}
+
+// loadtest sends greet requests to c.server when loadSendTime says until
+// c.duration is up or ctx is cancelled, without waiting for one request to
+// finish before sending the next. It then waits for the requests in
+// flight and writes a summary to w.
+func loadtest(ctx context.Context, client *http.Client, w io.Writer, c config) error {
+	stats := &loadStats{failures: map[string]int{}}
+	var wg sync.WaitGroup
+	start := time.Now()
+send:
+	for next := time.Duration(0); next < c.duration; next = loadSendTime(c, stats.sent) {
+		select {
+		case <-ctx.Done():
+			break send
+		case <-time.After(time.Until(start.Add(next))):
+		}
+
+		target := loadRequest(c.server, stats.sent)
+		stats.sent++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sentAt := time.Now()
+			resp, err := client.Get(target)
+			if err != nil {
+				stats.record(0, "error")
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				stats.record(0, strconv.Itoa(resp.StatusCode))
+				return
+			}
+			stats.record(time.Since(sentAt), "")
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	writeLoadSummary(w, stats, elapsed)
+	if n := stats.failed(); n > 0 {
+		return fmt.Errorf("%d of %d requests failed", n, stats.sent)
+	}
+	return nil
+}
+
+func writeLoadSummary(w io.Writer, stats *loadStats, elapsed time.Duration) {
+	fmt.Fprintf(w, "Sent %d requests in %s (%.1f/s)\n", stats.sent, elapsed.Round(time.Millisecond), float64(stats.sent)/elapsed.Seconds())
+
+	failed := stats.failed()
+	rate := 0.0
+	if stats.sent > 0 {
+		rate = float64(failed) * 100 / float64(stats.sent)
+	}
+	fmt.Fprintf(w, "Errors: %d (%.1f%%)\n", failed, rate)
+	reasons := []string{}
+	for reason := range stats.failures {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		label := "status " + reason
+		if reason == "error" {
+			label = "no response"
+		}
+		fmt.Fprintf(w, "  %s: %d\n", label, stats.failures[reason])
+	}
+
+	sort.Slice(stats.latencies, func(i, j int) bool { return stats.latencies[i] < stats.latencies[j] })
+	if len(stats.latencies) > 0 {
+		fmt.Fprintf(w, "Latency: p50 %s, p90 %s, p99 %s, max %s\n",
+			roundLatency(percentile(stats.latencies, 50)),
+			roundLatency(percentile(stats.latencies, 90)),
+			roundLatency(percentile(stats.latencies, 99)),
+			roundLatency(stats.latencies[len(stats.latencies)-1]))
+	}
+}
+
+func roundLatency(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+func runLoadtest(ctx context.Context, w io.Writer, c config) error {
+	client := &http.Client{Timeout: loadRequestTimeout}
+	return loadtest(ctx, client, w, c)
+}
@@ -0,0 +1,152 @@
+//go:build !noserve
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseLoadtestArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+		c    config
+	}{
+		{
+			args: []string{},
+			c:    loadtestDefaults(),
+		},
+		{
+			args: []string{"--server", "http://example.com:9000", "--rps", "100", "--duration", "60s", "--ramp", "10s"},
+			c:    config{command: "loadtest", server: "http://example.com:9000", rps: 100, duration: time.Minute, ramp: 10 * time.Second},
+		},
+		{
+			args: []string{"60s"},
+			err:  ErrInvalidArgCount,
+		},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"loadtest"}, tc.args...))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("expected error to be: %v, got: %v\n", tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if c.command != tc.c.command || c.server != tc.c.server || c.rps != tc.c.rps || c.duration != tc.c.duration || c.ramp != tc.c.ramp {
+			t.Errorf("expected config to be: %+v, got: %+v\n", tc.c, c)
+		}
+	}
+}
+
+func TestValidateLoadtestArgs(t *testing.T) {
+	tests := []struct {
+		c   config
+		err string
+	}{
+		{c: loadtestDefaults()},
+		{c: config{command: "loadtest", server: "localhost:8080", rps: 1, duration: time.Second}, err: `--server must be an http:// or https:// url, got "localhost:8080"`},
+		{c: config{command: "loadtest", server: defaultServer, rps: 0, duration: time.Second}, err: "--rps must be greater than 0"},
+		{c: config{command: "loadtest", server: defaultServer, rps: 1}, err: "--duration must be greater than 0"},
+		{c: config{command: "loadtest", server: defaultServer, rps: 1, duration: time.Second, ramp: time.Minute}, err: "--ramp must be between 0 and --duration"},
+	}
+	for _, tc := range tests {
+		err := validateArgs(tc.c)
+		if tc.err == "" && err != nil {
+			t.Errorf("expected nil error, got: %v\n", err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("expected error: %v, got: %v\n", tc.err, err)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{}
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{p: 0, expected: time.Millisecond},
+		{p: 50, expected: 50 * time.Millisecond},
+		{p: 99, expected: 99 * time.Millisecond},
+		{p: 100, expected: 100 * time.Millisecond},
+	}
+	for _, tc := range tests {
+		if got := percentile(sorted, tc.p); got != tc.expected {
+			t.Errorf("p%v: expected %v, got: %v\n", tc.p, tc.expected, got)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("expected 0 for no latencies, got: %v\n", got)
+	}
+}
+
+func TestLoadSendTime(t *testing.T) {
+	tests := []struct {
+		c        config
+		i        int
+		expected time.Duration
+	}{
+		{c: config{rps: 100}, i: 0, expected: 0},
+		{c: config{rps: 100}, i: 150, expected: 1500 * time.Millisecond},
+		// half way through the ramp the rate is half of rps, so a quarter
+		// of the ramp's 500 requests are out
+		{c: config{rps: 100, ramp: 10 * time.Second}, i: 125, expected: 5 * time.Second},
+		{c: config{rps: 100, ramp: 10 * time.Second}, i: 500, expected: 10 * time.Second},
+		{c: config{rps: 100, ramp: 10 * time.Second}, i: 600, expected: 11 * time.Second},
+	}
+	for _, tc := range tests {
+		if got := loadSendTime(tc.c, tc.i); got != tc.expected {
+			t.Errorf("request %v at %v/s, %v ramp: expected %v, got: %v\n", tc.i, tc.c.rps, tc.c.ramp, tc.expected, got)
+		}
+	}
+}
+
+func TestLoadtest(t *testing.T) {
+	srv := httptest.NewServer(newServeMux(serveDefaults()))
+	defer srv.Close()
+
+	out := new(bytes.Buffer)
+	c := config{command: "loadtest", server: srv.URL, rps: 50, duration: 200 * time.Millisecond}
+	if err := loadtest(context.Background(), srv.Client(), out, c); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if !strings.HasPrefix(out.String(), "Sent 10 requests in ") {
+		t.Errorf("expected 10 requests at 50/s for 200ms, got: %q\n", out.String())
+	}
+	if !strings.Contains(out.String(), "Errors: 0 (0.0%)\n") || !strings.Contains(out.String(), "Latency: p50 ") {
+		t.Errorf("expected no errors and latencies, got: %q\n", out.String())
+	}
+}
+
+func TestLoadtestErrors(t *testing.T) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1)%2 == 0 {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	out := new(bytes.Buffer)
+	c := config{command: "loadtest", server: srv.URL, rps: 20, duration: 200 * time.Millisecond}
+	err := loadtest(context.Background(), srv.Client(), out, c)
+	if err == nil || err.Error() != "2 of 4 requests failed" {
+		t.Errorf("expected every other request to fail, got: %v\n", err)
+	}
+	if !strings.Contains(out.String(), "Errors: 2 (50.0%)\n  status 500: 2\n") {
+		t.Errorf("expected the failures by status, got: %q\n", out.String())
+	}
+}
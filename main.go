@@ -2,108 +2,653 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"os/user"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jordanengstrom/name-cli-app.git/pkg/greeter"
 )
 
 type config struct {
-	numTimes   int
-	printUsage bool
+	numTimes      int
+	forever       bool          // greet until interrupted instead of numTimes
+	interval      time.Duration // between greetings
+	name          string
+	inputTimeout  time.Duration // how long to wait for the name, 0 for ever
+	defaultName   string        // greeted if the name isn't entered in time or piped in
+	nameFallback  bool          // greet defaultName or the OS user if stdin has no name
+	maxRetries    int           // prompts again after an empty name
+	noPrompt      bool          // read the name without asking for it, set when stdin isn't a terminal
+	forcePrompt   bool          // ask for the name even if stdin isn't a terminal
+	printUsage    bool
+	explain       bool
+	usageFormat   string
+	printSchema   string
+	printExamples bool
+	exampleTopic  string
+	reportFile    string
+	filterMode    string
+	denylistFile  string
+	allowlistFile string
+	inputEncoding string // of piped names and the name lists, see decodeInput
+	fallbackMsg   string
+	matchMin      float64
+	suppressFile  string
+	auditFile     string
+	session       bool
+	stdinBatch    bool
+	statsFile     string
+	at            time.Time
+	in            time.Duration
+	command       string // the subcommand to run, empty for greet
+	shell         string
+	configFile    string
+	pronounce     bool // print the allowlist pronunciation after the greeting
+	template      string
+	outputFile    string
+	appendOutput  bool
+	outEncoding   string    // of the --output-file, utf-8 if empty
+	outputBOM     string    // auto, always or never
+	greetOut      io.Writer // where greetings go if not with the prompts, see runWithOutput
+	greeted       *int      // adds up the greetings written, for --report
+	output        string
+	color         string // auto, always or never
+	banner        bool   // greet in large letters of font
+	font          string
+	theme         string
+	lang          string
+	failAfter     int // the hidden chaos flags, see chaosOutput
+	slowWrites    time.Duration
+	corrupt       bool
+	listenAddr    string
+	maxTimes      int
+	idleTimeout   time.Duration
+	handoffSocket string
+	upgrade       bool
+	short         bool   // version --short
+	assetsAction  string // list or export
+	assetsDir     string
+	lintFile      string
+	diffFiles     []string
+	noHistory     bool
+	historyName   string // history --name
+	historyLast   int
+	showStreak    bool   // print the streak of the name after the greeting
+	statsAction   string // streaks
+	stateAction   string // path, size or clean
+	force         bool   // assets export --force, state clean --yes
+	server        string // the loadtest options
+	rps           int
+	duration      time.Duration
+	ramp          time.Duration
+	hold          time.Duration
+	printer       string
+	contact       string
+	badgeFile     string
+	badgeLayout   string
+	serviceAction string // the service options, launchd so far
+	serviceLabel  string
+	serviceFile   string
+	serviceLog    string
+	serviceLoad   bool
+	serviceArgs   []string // what the job runs the tool with
+	// origin records where each option was set, keyed by option name.
+	origin map[string]string
 }
 
-var usageString = fmt.Sprintf(`Usage: %s <integer> [-h|--help]
-
-A greeter application which prints the name you entered <integer> number of times.
-`, os.Args[0])
+func validateArgs(c config) error {
+	if c.printUsage {
+		switch c.usageFormat {
+		case "", usageText, usageMarkdown, usageMan:
+			return nil
+		}
+		return fmt.Errorf("unknown usage format %q, expected %s, %s or %s", c.usageFormat, usageText, usageMarkdown, usageMan)
+	}
+	if err := validateLang(c.lang); err != nil {
+		return err
+	}
+	switch c.command {
+	case "examples", "version", "features", "assets", "completion", "internal", "lint", "diff", "history", "stats", "state":
+		return nil
+	case "serve":
+		return validateServeArgs(c)
+	case "display":
+		return validateDisplayArgs(c)
+	case "badge":
+		return validateBadgeArgs(c)
+	case "loadtest":
+		return validateLoadtestArgs(c)
+	case "service":
+		return validateServiceArgs(c)
+	}
+	if c.printSchema != "" {
+		return nil
+	}
+	if !(c.numTimes > 0) && !c.forever {
+		return ErrNonPositiveCount
+	}
+	if c.forever && (c.session || c.stdinBatch) {
+		return errors.New("--forever cannot be used with --session or --stdin-batch")
+	}
+	if c.forever && c.reportFile != "" {
+		return errors.New("--report cannot be used with --forever, the run has no end to report on")
+	}
+	if c.interval < 0 {
+		return errors.New("--interval must not be negative")
+	}
+	if c.inputTimeout < 0 {
+		return errors.New("--input-timeout must not be negative")
+	}
+	if c.inputTimeout > 0 && (c.session || c.stdinBatch) {
+		return errors.New("--input-timeout cannot be used with --session or --stdin-batch")
+	}
+	if c.maxRetries < 0 {
+		return errors.New("--max-retries must not be negative")
+	}
+	if c.noPrompt && c.forcePrompt {
+		return errors.New("--no-prompt and --force-prompt cannot be used together")
+	}
 
-func printUsage(w io.Writer) {
-	fmt.Fprintf(w, usageString)
+	if !c.at.IsZero() && c.in != 0 {
+		return errors.New("--at and --in cannot be used together")
+	}
+	if c.in < 0 {
+		return errors.New("--in must not be negative")
+	}
+	if c.session && (!c.at.IsZero() || c.in != 0) {
+		return errors.New("--at and --in cannot be used with --session")
+	}
+	if c.origin["name"] != "" && strings.TrimSpace(c.name) == "" {
+		return ErrEmptyName
+	}
+	if c.name != "" && c.session {
+		return errors.New("--name cannot be used with --session")
+	}
+	if c.stdinBatch && (c.session || c.name != "") {
+		return errors.New("--stdin-batch cannot be used with --session or --name")
+	}
+	if c.stdinBatch && (!c.at.IsZero() || c.in != 0) {
+		return errors.New("--at and --in cannot be used with --stdin-batch")
+	}
+	if c.customTemplate() {
+		if _, err := parseTemplate(c.template, c.messages()); err != nil {
+			return err
+		}
+	}
+	if c.pronounce && c.allowlistFile == "" {
+		return errors.New("--show-pronunciation needs an --allowlist to read pronunciations from")
+	}
+	if c.output != "" {
+		if _, ok := encoders[c.output]; !ok {
+			return fmt.Errorf("unknown output format %q, expected one of: %s", c.output, strings.Join(outputNames(), ", "))
+		}
+	}
+	if err := validateColor(c); err != nil {
+		return err
+	}
+	if c.banner && c.output == outputJSON {
+		return errors.New("--banner cannot be used with --output json")
+	}
+	if c.banner {
+		if err := validateFont(c.font); err != nil {
+			return err
+		}
+	}
+	if c.pronounce && c.output == outputJSON {
+		return errors.New("--show-pronunciation cannot be used with --output json")
+	}
+	if c.showStreak && c.output == outputJSON {
+		return errors.New("--show-streak cannot be used with --output json")
+	}
+	if c.showStreak && c.noHistory {
+		return errors.New("--show-streak needs the history, it cannot be used with --no-history")
+	}
+	if c.suppressFile != "" && !c.session && !c.stdinBatch {
+		return errors.New("--suppress can only be used with --session or --stdin-batch")
+	}
+	if c.appendOutput && c.outputFile == "" {
+		return errors.New("--append needs an --output-file to append to")
+	}
+	if err := validateOutputEncoding(c); err != nil {
+		return err
+	}
+	if c.failAfter < 0 {
+		return errors.New("--fail-after must not be negative")
+	}
+	if c.statsFile != "" && !c.session {
+		return errors.New("--stats can only be used with --session")
+	}
+	return validateNameChecks(c)
 }
 
-func validateArgs(c config) error {
-	if !(c.numTimes > 0) {
-		return errors.New("must specify a number greater than 0")
+// validateNameChecks checks the options added by addNameCheckFlags.
+func validateNameChecks(c config) error {
+	if c.matchMin < 0 || c.matchMin > 1 {
+		return errors.New("match threshold must be between 0 and 1")
 	}
-	return nil
+	if c.auditFile != "" && c.suppressFile == "" {
+		return errors.New("--audit-log needs a --suppress list to audit")
+	}
+	if c.filterMode != "" && c.filterMode != filterReject && c.filterMode != filterMask {
+		return fmt.Errorf("unknown filter mode %q, expected %s or %s", c.filterMode, filterReject, filterMask)
+	}
+	return validateInputEncoding(c.inputEncoding)
 }
 
-func parseArgs(args []string) (config, error) {
-	var numTimes int
-	var err error
-	c := config{}
-	if len(args) != 1 {
-		return c, errors.New("invalid number of arguments")
+// parseGreetArgs parses the arguments of the greet command, which is also
+// what runs when no command is given.
+func parseGreetArgs(args []string) (config, error) {
+	var positional []string
+	c := defaultConfig()
+
+	fs := newFlagSet(&c)
+	// flag stops at the first argument that isn't a flag, keep going so
+	// flags can come after the count too
+	for {
+		if err := fs.Parse(args); err != nil {
+			return config{}, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
 
-	if args[0] == "-h" || args[0] == "--help" {
-		c.printUsage = true
-		return c, nil
+	if c.printUsage {
+		return config{printUsage: true, usageFormat: c.usageFormat, lang: c.lang}, nil
+	}
+	if c.printSchema != "" {
+		return config{printSchema: c.printSchema}, nil
 	}
 
-	numTimes, err = strconv.Atoi(args[0])
-	if err != nil {
-		return c, err
+	timesSet := false
+	fs.Visit(func(f *flag.Flag) {
+		name := f.Name
+		if long, ok := flagAliases[name]; ok {
+			name = long
+		}
+		if name == "times" {
+			timesSet = true
+		}
+		c.setOrigin(name, originFlag)
+	})
+
+	switch {
+	case len(positional) > 1:
+		return config{}, ErrInvalidArgCount
+	case len(positional) == 1 && timesSet:
+		return config{}, errors.New("the count was given both as an argument and with -n")
+	case len(positional) == 1:
+		numTimes, err := strconv.Atoi(positional[0])
+		if err != nil {
+			return config{}, &ParseError{Token: positional[0], Err: err}
+		}
+		c.numTimes = numTimes
+		c.setOrigin("times", originFlag)
+	}
+
+	if err := resolveConfig(fs, &c); err != nil {
+		return config{}, err
+	}
+	// checked once the environment and config file are in, a count on the
+	// command line wins over --forever from them
+	if c.forever && c.origin["times"] == originFlag {
+		if c.origin["forever"] == originFlag {
+			return config{}, errors.New("the count cannot be given with --forever")
+		}
+		c.forever = false
+		c.setOrigin("forever", c.origin["forever"]+", overridden by the count")
+	}
+	if c.forever {
+		// a count from the environment or config file doesn't apply
+		c.numTimes = 0
+		if c.origin["interval"] == "" {
+			c.interval = defaultForeverInterval
+		}
+	} else if c.origin["times"] == "" {
+		return config{}, ErrInvalidArgCount
+	}
+
+	if c.denylistFile != "" && c.filterMode == "" {
+		c.filterMode = filterReject
+		c.setOrigin("filter", "implied by --denylist")
 	}
-	c.numTimes = numTimes
 
 	return c, nil
 }
 
-func getName(r io.Reader, w io.Writer) (string, error) {
-	msg := "Your name please? Press the return key when done.\n"
-	fmt.Fprint(w, msg)
-	scanner := bufio.NewScanner(r)
-	scanner.Scan()
-	if err := scanner.Err(); err != nil {
-		return "", err
-	}
+// The errors parseArgs, validateArgs and runCmd return for the mistakes
+// callers may want to tell apart, with errors.Is.
+var (
+	ErrInvalidArgCount  = errors.New("invalid number of arguments")
+	ErrNonPositiveCount = errors.New("must specify a number greater than 0")
+	ErrEmptyName        = greeter.ErrNoName
+)
+
+// ParseError is returned for an argument that isn't what it should be,
+// like a count that isn't a number. Token is the argument as given.
+type ParseError struct {
+	Token string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
 
-	name := scanner.Text()
-	if len(name) == 0 {
-		return "", errors.New("you didn't enter your name")
+// defaultMaxRetries is how many more times the name is asked for after an
+// empty entry unless --max-retries says otherwise.
+const defaultMaxRetries = 3
+
+// exitInputTimeout is the exit status when no name was entered within
+// --input-timeout, the one timeout(1) uses.
+const exitInputTimeout = 124
+
+// errInputTimeout is what getName returns when nothing was entered
+// within --input-timeout.
+var errInputTimeout = greeter.ErrInputTimeout
+
+// getName asks for the name in the language of m, see greeter.AskName.
+func getName(scanner *bufio.Scanner, w io.Writer, m messages, timeout time.Duration, retries int) (string, error) {
+	return greeter.AskName(scanner, w, m.prompt, timeout, retries)
+}
+
+// defaultForeverInterval is the wait between greetings with --forever
+// unless --interval says otherwise.
+const defaultForeverInterval = time.Second
+
+// greeterConfig is the part of c greeter.Greet needs, in the language
+// and with the --template and --output of c.
+func (c config) greeterConfig() (greeter.Config, error) {
+	gc := greeter.Config{
+		Times:    c.numTimes,
+		Forever:  c.forever,
+		Interval: c.interval,
+		Message:  c.messages().greeting,
+	}
+	if c.customTemplate() {
+		t, err := parseTemplate(c.template, c.messages())
+		if err != nil {
+			return greeter.Config{}, err
+		}
+		gc.Template = t
+		gc.FormatNumber = c.messages().formatNumber
 	}
+	if enc := c.encoder(); enc != (textEncoder{}) {
+		gc.Encode = enc.appendLine
+	}
+	return gc, nil
+}
 
-	return name, nil
+// greetUser greets name c.numTimes times on w, or until ctx is done with
+// c.forever, see greeter.Greet, in color and as a --banner if asked for.
+func greetUser(ctx context.Context, c config, name string, w io.Writer) error {
+	_, err := greetCounting(ctx, c, name, w)
+	return err
 }
 
-func greetUser(c config, name string, w io.Writer) {
-	msg := fmt.Sprintf("Nice to meet you %s\n", name)
-	for i := 0; i < c.numTimes; i++ {
-		fmt.Fprint(w, msg)
+// greetCounting is greetUser returning how many times name was greeted,
+// which with c.forever or Ctrl+C isn't known until it stops.
+func greetCounting(ctx context.Context, c config, name string, w io.Writer) (int, error) {
+	gc, err := c.greeterConfig()
+	if err != nil {
+		return 0, err
 	}
+	t, colored := c.colorTheme(w)
+	switch {
+	case c.banner:
+		fnt, err := loadFont(c.font)
+		if err != nil {
+			return 0, err
+		}
+		b := &bannerEncoder{fnt: fnt}
+		if colored {
+			b.sgr = t.greeting
+		}
+		gc.Encode = b.appendLine
+	case colored && gc.Encode == nil:
+		gc.Encode = t.appendLine
+	}
+	return greeter.GreetCount(ctx, w, gc, name)
 }
 
-func runCmd(r io.Reader, w io.Writer, c config) error {
+func runCmd(ctx context.Context, r io.Reader, w io.Writer, c config) error {
 	if c.printUsage {
-		printUsage(w)
+		if c.command != "" {
+			return printCommandUsage(w, os.Args[0], c.command, c.messages())
+		}
+		printUsage(w, c.usageFormat, c.messages())
 		return nil
 	}
+	if c.printSchema != "" {
+		return printSchema(w, c.printSchema)
+	}
+	if c.printExamples {
+		return printExamples(w, os.Args[0], c.exampleTopic)
+	}
+	if c.explain {
+		explain(w, c)
+	}
 
-	name, err := getName(r, w)
+	// share one scanner so follow-up questions read the lines after the name
+	scanner := bufio.NewScanner(r)
+	_, err := greetVisitor(ctx, scanner, w, c)
+	if err == io.EOF {
+		return ErrEmptyName
+	}
+	return err
+}
+
+// interactive reports whether there is someone at the terminal to answer
+// questions, as opposed to names coming from flags or a batch.
+func (c config) interactive() bool {
+	return c.name == "" && !c.stdinBatch
+}
+
+// greetVisitor asks for a name, unless one was given with --name, checks
+// it against the denylist and allowlist if configured and greets it, on
+// c.greetOut if set. It returns the name that was greeted.
+func greetVisitor(ctx context.Context, scanner *bufio.Scanner, w io.Writer, c config) (string, error) {
+	name, err := checkName(scanner, w, c)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if err := waitForSchedule(ctx, w, c); err != nil {
+		return "", err
+	}
+	out := w
+	if c.greetOut != nil {
+		out = c.greetOut
+	}
+	greeted, err := greetCounting(ctx, c, name, out)
+	if c.greeted != nil {
+		*c.greeted += greeted
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := recordGreeting(c, name, greeted); err != nil {
+		return "", err
+	}
+	if c.pronounce {
+		p, err := pronunciation(c, name)
+		if err != nil {
+			return "", err
+		}
+		if p != "" {
+			fmt.Fprintf(out, "(pronounced %s)\n", p)
+		}
 	}
-	greetUser(c, name, w)
-	return nil
+	if c.showStreak {
+		note, err := streakNote(name)
+		if err != nil {
+			return "", err
+		}
+		if note != "" {
+			fmt.Fprintln(out, note)
+		}
+	}
+	return name, nil
+}
+
+// fallbackName is who to greet when no name came on stdin and there is
+// nobody at the terminal to ask: --default-name, from the flag, the
+// environment or the config file, or else the name of the OS user.
+func fallbackName(c config) string {
+	if c.defaultName != "" {
+		return c.defaultName
+	}
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	// DOMAIN\name on Windows
+	name := u.Username
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// readsTerminal reports whether r reads from a terminal rather than a
+// pipe, a file or a test.
+func readsTerminal(r io.Reader) bool {
+	if cr, ok := r.(contextReader); ok {
+		r = cr.r
+	}
+	f, ok := r.(*os.File)
+	if !ok || !isTerminal(f) {
+		return false
+	}
+	// /dev/null is a character device as well, and what cron and services
+	// get as stdin
+	fi, err := f.Stat()
+	null, nerr := os.Stat(os.DevNull)
+	return err != nil || nerr != nil || !os.SameFile(fi, null)
+}
+
+// checkName is the part of greetVisitor that finds out who to greet: it
+// asks for the name if needed and runs it through the suppression list,
+// the denylist and the allowlist.
+func checkName(scanner *bufio.Scanner, w io.Writer, c config) (string, error) {
+	var err error
+	name := c.name
+	if name == "" {
+		pw, m := w, c.messages()
+		if c.noPrompt {
+			pw = io.Discard
+		} else if t, ok := c.colorTheme(w); ok {
+			m.prompt = paint(t.prompt, m.prompt)
+		}
+		name, err = getName(scanner, pw, m, c.inputTimeout, c.maxRetries)
+		if err == errInputTimeout && c.defaultName != "" {
+			// as if given with --name, so nothing more is asked
+			c.name, name, err = c.defaultName, c.defaultName, nil
+		}
+		if (err == io.EOF || err == ErrEmptyName) && c.nameFallback {
+			if fallback := fallbackName(c); fallback != "" {
+				c.name, name, err = fallback, fallback, nil
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if c.suppressFile != "" {
+		if err := suppress(c, name); err != nil {
+			return "", err
+		}
+	}
+	if c.filterMode != "" {
+		d, err := loadDenylist(c.denylistFile, c.inputEncoding)
+		if err != nil {
+			return "", err
+		}
+		name, err = d.filter(name, c.filterMode)
+		if err != nil {
+			return "", err
+		}
+	}
+	if c.allowlistFile != "" {
+		name, err = checkAllowlist(scanner, w, c, name)
+		if err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// runWithOutput runs the command with the greetings going to stdout, or
+// to the --output-file with prompts and errors left on stdout.
+func runWithOutput(ctx context.Context, c config) (int, error) {
+	in := contextReader{ctx: ctx, r: os.Stdin}
+	if c.outputFile == "" {
+		return runCommand(ctx, in, chaosOutput(os.Stdout, c), c)
+	}
+	out, err := createOutputFile(c.outputFile, c.appendOutput)
+	if err != nil {
+		return 0, err
+	}
+	bom := c.outputBOM
+	if c.appendOutput && out.size > 0 {
+		// only files start with one
+		bom = bomNever
+	}
+	enc, err := encodeOutput(out, c.outEncoding, bom)
+	if err != nil {
+		out.finish(err)
+		return 0, fmt.Errorf("could not write output file: %w", err)
+	}
+	c.greetOut = chaosOutput(enc, c)
+	visitors, err := runCommand(ctx, in, os.Stdout, c)
+	if ferr := out.finish(err); err == nil {
+		err = ferr
+	}
+	return visitors, err
 }
 
 func main() {
+	r := newRunReport()
 	c, err := parseArgs(os.Args[1:])
-	if err != nil {
-		fmt.Fprintln(os.Stdout, err)
+	if err == nil {
+		err = validateArgs(c)
 	}
-	err = validateArgs(c)
 	if err != nil {
-		fmt.Fprintln(os.Stdout, err)
-		os.Exit(1)
+		err = usageError{err}
+	}
+	visitors, greeted := 0, 0
+	c.greeted = &greeted
+	// Ctrl+C and SIGTERM stop the run, see exitInterrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if err == nil {
+		visitors, err = runWithOutput(ctx, c)
+	}
+	interrupted := ctx.Err() != nil
+	stop()
+
+	if c.reportFile != "" {
+		r.finish(c, visitors, greeted, err)
+		if werr := writeReport(c.reportFile, r); werr != nil {
+			fmt.Fprintln(os.Stdout, werr)
+		}
 	}
 
-	err = runCmd(os.Stdin, os.Stdout, c)
 	if err != nil {
-		fmt.Fprintln(os.Stdout, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stdout, c.messages().errorText(err))
+		os.Exit(exitCode(err, interrupted))
 	}
 }
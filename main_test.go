@@ -1,16 +1,25 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
+	"os/user"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/jordanengstrom/name-cli-app.git/pkg/greeter"
 )
 
 type testConfig struct {
@@ -28,17 +37,38 @@ func TestMain(m *testing.M) {
 		binaryName = "application-test"
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	// linking net/http regularly takes longer than half a second on a cold cache
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// build the app:
 	cmd := exec.CommandContext(ctx, "go", "build", "-o", binaryName)
-	err := cmd.Run()
+	out, err := cmd.CombinedOutput()
 
 	if err != nil {
+		log.Printf("Error building the test binary: %v\n%s", err, out)
 		os.Exit(1)
 	}
 
+	// keep a config file in the developer's home, NAME_CLI_ variables or
+	// their locale from changing the results
+	configHome, err := os.MkdirTemp("", "name-cli-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(configHome)
+	os.Setenv("XDG_CONFIG_HOME", configHome)
+	// and the greetings of the tests out of the developer's history
+	os.Setenv("XDG_DATA_HOME", configHome)
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, envPrefix) {
+			os.Unsetenv(kv[:strings.Index(kv, "=")])
+		}
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		os.Unsetenv(name)
+	}
+
 	// cleanup the test binary:
 	defer func() {
 		err = os.Remove(binaryName)
@@ -49,6 +79,28 @@ func TestMain(m *testing.M) {
 	m.Run()
 }
 
+// withDefaults fills in the fields parseArgs sets to their defaults when
+// the flag isn't given.
+func withDefaults(c config) config {
+	d := defaultConfig()
+	if c.fallbackMsg == "" {
+		c.fallbackMsg = d.fallbackMsg
+	}
+	if c.matchMin == 0 {
+		c.matchMin = d.matchMin
+	}
+	if c.template == "" {
+		c.template = d.template
+	}
+	if c.output == "" {
+		c.output = d.output
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = d.maxRetries
+	}
+	return c
+}
+
 func TestParseArgs(t *testing.T) {
 	tests := []testConfig{
 		{
@@ -59,7 +111,7 @@ func TestParseArgs(t *testing.T) {
 		{
 			args:   []string{"10"},
 			err:    nil,
-			config: config{printUsage: false, numTimes: 10},
+			config: withDefaults(config{printUsage: false, numTimes: 10}),
 		},
 		{
 			args:   []string{"abc"},
@@ -68,9 +120,124 @@ func TestParseArgs(t *testing.T) {
 		},
 		{
 			args:   []string{"1", "foo"},
-			err:    errors.New("invalid number of arguments"),
+			err:    ErrInvalidArgCount,
 			config: config{printUsage: false, numTimes: 0},
 		},
+		{
+			args:   []string{"-n", "4"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 4}),
+		},
+		{
+			args:   []string{"--times", "4", "--session"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 4, session: true}),
+		},
+		{
+			args:   []string{"-n", "4", "4"},
+			err:    errors.New("the count was given both as an argument and with -n"),
+			config: config{},
+		},
+		{
+			args:   []string{},
+			err:    ErrInvalidArgCount,
+			config: config{},
+		},
+		{
+			args:   []string{"--bogus", "1"},
+			err:    errors.New("flag provided but not defined: -bogus"),
+			config: config{},
+		},
+		{
+			args:   []string{"--match-threshold", "0.5", "1"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 1, matchMin: 0.5}),
+		},
+		{
+			args:   []string{"-name", "Benny", "5"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 5, name: "Benny"}),
+		},
+		{
+			args:   []string{"--stdin-batch", "3"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 3, stdinBatch: true}),
+		},
+		{
+			args:   []string{"--explain", "3"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 3, explain: true}),
+		},
+		{
+			args:   []string{"--report", "run.json", "3"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 3, reportFile: "run.json"}),
+		},
+		{
+			args:   []string{"3", "--report"},
+			err:    errors.New("flag needs an argument: -report"),
+			config: config{numTimes: 0},
+		},
+		{
+			args:   []string{"--denylist", "words.txt", "2"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 2, denylistFile: "words.txt", filterMode: "reject"}),
+		},
+		{
+			args:   []string{"2", "--filter", "mask"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 2, filterMode: "mask"}),
+		},
+		{
+			args:   []string{"--allowlist", "guests.txt", "--fallback", "Welcome, guest!", "1"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 1, allowlistFile: "guests.txt", fallbackMsg: "Welcome, guest!"}),
+		},
+		{
+			args:   []string{"examples", "kiosk"},
+			err:    nil,
+			config: config{printExamples: true, exampleTopic: "kiosk"},
+		},
+		{
+			args:   []string{"examples", "kiosk", "extra"},
+			err:    ErrInvalidArgCount,
+			config: config{},
+		},
+		{
+			args:   []string{"5", "--usage-format", "man"},
+			err:    nil,
+			config: config{printUsage: true, usageFormat: "man"},
+		},
+		{
+			args:   []string{"--session", "--schema", "report"},
+			err:    nil,
+			config: config{printSchema: "report"},
+		},
+		{
+			args:   []string{"--session", "--stats", "stats.json", "1"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 1, session: true, statsFile: "stats.json"}),
+		},
+		{
+			args:   []string{"--in", "10m", "1"},
+			err:    nil,
+			config: withDefaults(config{numTimes: 1, in: 10 * time.Minute}),
+		},
+		{
+			args:   []string{"--in", "soon", "1"},
+			err:    errors.New("invalid value \"soon\" for flag -in: parse error"),
+			config: config{numTimes: 0},
+		},
+		{
+			args:   []string{"--at", "5pm", "1"},
+			err:    errors.New("invalid value \"5pm\" for flag -at: invalid time of day \"5pm\", expected HH:MM"),
+			config: config{numTimes: 0},
+		},
+		{
+			args:   []string{"--match-threshold", "nope", "1"},
+			err:    errors.New("invalid value \"nope\" for flag -match-threshold: parse error"),
+			config: config{numTimes: 0},
+		},
 	}
 
 	for _, tc := range tests {
@@ -87,6 +254,129 @@ func TestParseArgs(t *testing.T) {
 		if c.numTimes != tc.numTimes {
 			t.Errorf("expected numTimes to be: %v, got: %v\n", tc.numTimes, c.numTimes)
 		}
+		if c.name != tc.name {
+			t.Errorf("expected name to be: %v, got: %v\n", tc.name, c.name)
+		}
+		if c.usageFormat != tc.usageFormat {
+			t.Errorf("expected usageFormat to be: %v, got: %v\n", tc.usageFormat, c.usageFormat)
+		}
+		if c.printExamples != tc.printExamples {
+			t.Errorf("expected printExamples to be: %v, got: %v\n", tc.printExamples, c.printExamples)
+		}
+		if c.exampleTopic != tc.exampleTopic {
+			t.Errorf("expected exampleTopic to be: %v, got: %v\n", tc.exampleTopic, c.exampleTopic)
+		}
+		if c.printSchema != tc.printSchema {
+			t.Errorf("expected printSchema to be: %v, got: %v\n", tc.printSchema, c.printSchema)
+		}
+		if c.explain != tc.explain {
+			t.Errorf("expected explain to be: %v, got: %v\n", tc.explain, c.explain)
+		}
+		if c.reportFile != tc.reportFile {
+			t.Errorf("expected reportFile to be: %v, got: %v\n", tc.reportFile, c.reportFile)
+		}
+		if c.filterMode != tc.filterMode {
+			t.Errorf("expected filterMode to be: %v, got: %v\n", tc.filterMode, c.filterMode)
+		}
+		if c.denylistFile != tc.denylistFile {
+			t.Errorf("expected denylistFile to be: %v, got: %v\n", tc.denylistFile, c.denylistFile)
+		}
+		if c.allowlistFile != tc.allowlistFile {
+			t.Errorf("expected allowlistFile to be: %v, got: %v\n", tc.allowlistFile, c.allowlistFile)
+		}
+		if c.fallbackMsg != tc.fallbackMsg {
+			t.Errorf("expected fallbackMsg to be: %v, got: %v\n", tc.fallbackMsg, c.fallbackMsg)
+		}
+		if c.matchMin != tc.matchMin {
+			t.Errorf("expected matchMin to be: %v, got: %v\n", tc.matchMin, c.matchMin)
+		}
+		if c.session != tc.session {
+			t.Errorf("expected session to be: %v, got: %v\n", tc.session, c.session)
+		}
+		if c.stdinBatch != tc.stdinBatch {
+			t.Errorf("expected stdinBatch to be: %v, got: %v\n", tc.stdinBatch, c.stdinBatch)
+		}
+		if c.statsFile != tc.statsFile {
+			t.Errorf("expected statsFile to be: %v, got: %v\n", tc.statsFile, c.statsFile)
+		}
+		if c.in != tc.in {
+			t.Errorf("expected in to be: %v, got: %v\n", tc.in, c.in)
+		}
+	}
+}
+
+func TestParseArgsForever(t *testing.T) {
+	tests := []struct {
+		args     []string
+		err      error
+		interval time.Duration
+	}{
+		{args: []string{"--forever"}, interval: defaultForeverInterval},
+		{args: []string{"--forever", "--interval", "250ms"}, interval: 250 * time.Millisecond},
+		{args: []string{"--forever", "--interval", "0s"}, interval: 0},
+		{args: []string{"--forever", "3"}, err: errors.New("the count cannot be given with --forever")},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(tc.args)
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("%v: expected error to be: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("%v: expected nil error, got: %v\n", tc.args, err)
+		}
+		if tc.err == nil && (!c.forever || c.numTimes != 0 || c.interval != tc.interval) {
+			t.Errorf("%v: expected forever every %v, got: %+v\n", tc.args, tc.interval, c)
+		}
+	}
+
+	// a count from the environment doesn't get in the way
+	t.Run("times from env", func(t *testing.T) {
+		t.Setenv("NAME_CLI_TIMES", "3")
+		if c, err := parseArgs([]string{"--forever"}); err != nil || c.numTimes != 0 {
+			t.Errorf("expected --forever to win over $NAME_CLI_TIMES, got: %d, %v\n", c.numTimes, err)
+		}
+	})
+
+	// nor does --forever from the environment or config file with a count
+	// on the command line
+	t.Run("forever from env", func(t *testing.T) {
+		t.Setenv("NAME_CLI_FOREVER", "1")
+		if c, err := parseArgs([]string{"5"}); err != nil || c.forever || c.numTimes != 5 {
+			t.Errorf("expected the count to win over $NAME_CLI_FOREVER, got: %+v, %v\n", c, err)
+		}
+	})
+	t.Run("forever from config file", func(t *testing.T) {
+		path := writeConfigFile(t, t.TempDir(), "config.yaml", "forever: true\n")
+		if c, err := parseArgs([]string{"--config", path, "5"}); err != nil || c.forever || c.numTimes != 5 {
+			t.Errorf("expected the count to win over forever in the config file, got: %+v, %v\n", c, err)
+		}
+	})
+}
+
+func TestSentinelErrors(t *testing.T) {
+	if _, err := parseArgs([]string{"1", "foo"}); !errors.Is(err, ErrInvalidArgCount) {
+		t.Errorf("expected ErrInvalidArgCount, got: %v\n", err)
+	}
+	if _, err := parseArgs([]string{"version", "extra"}); !errors.Is(err, ErrInvalidArgCount) {
+		t.Errorf("expected ErrInvalidArgCount from a subcommand, got: %v\n", err)
+	}
+
+	_, err := parseArgs([]string{"abc"})
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Token != "abc" {
+		t.Fatalf("expected a ParseError for \"abc\", got: %v\n", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected the ParseError to wrap strconv.ErrSyntax, got: %v\n", err)
+	}
+
+	if err := validateArgs(config{numTimes: -1}); !errors.Is(err, ErrNonPositiveCount) {
+		t.Errorf("expected ErrNonPositiveCount, got: %v\n", err)
+	}
+	err = runCmd(context.Background(), strings.NewReader("\n"), new(bytes.Buffer), withDefaults(config{numTimes: 1}))
+	if !errors.Is(err, ErrEmptyName) {
+		t.Errorf("expected ErrEmptyName, got: %v\n", err)
 	}
 }
 
@@ -97,16 +387,103 @@ func TestValidateArgs(t *testing.T) {
 	}{
 		{
 			c:   config{},
-			err: errors.New("must specify a number greater than 0"),
+			err: ErrNonPositiveCount,
+		},
+		{
+			c:   config{printUsage: true, usageFormat: "xml"},
+			err: errors.New(`unknown usage format "xml", expected text, markdown or man`),
+		},
+		{
+			c:   config{printSchema: "report"},
+			err: nil,
 		},
 		{
 			c:   config{numTimes: -1},
-			err: errors.New("must specify a number greater than 0"),
+			err: ErrNonPositiveCount,
 		},
 		{
 			c:   config{numTimes: 10},
 			err: nil,
 		},
+		{
+			c:   config{numTimes: 10, in: time.Minute, at: time.Date(0, 1, 1, 17, 0, 0, 0, time.UTC)},
+			err: errors.New("--at and --in cannot be used together"),
+		},
+		{
+			c:   config{numTimes: 10, session: true, in: time.Minute},
+			err: errors.New("--at and --in cannot be used with --session"),
+		},
+		{
+			c:   config{numTimes: 10, origin: map[string]string{"name": originFlag}},
+			err: ErrEmptyName,
+		},
+		{
+			c:   config{numTimes: 10, name: "Benny", session: true},
+			err: errors.New("--name cannot be used with --session"),
+		},
+		{
+			c:   config{numTimes: 10, stdinBatch: true, session: true},
+			err: errors.New("--stdin-batch cannot be used with --session or --name"),
+		},
+		{
+			c:   config{numTimes: 10, stdinBatch: true, in: time.Second},
+			err: errors.New("--at and --in cannot be used with --stdin-batch"),
+		},
+		{
+			c:   config{numTimes: 10, statsFile: "stats.json"},
+			err: errors.New("--stats can only be used with --session"),
+		},
+		{
+			c:   config{numTimes: 10, appendOutput: true},
+			err: errors.New("--append needs an --output-file to append to"),
+		},
+		{
+			c:   config{numTimes: 10, suppressFile: "optout.txt"},
+			err: errors.New("--suppress can only be used with --session or --stdin-batch"),
+		},
+		{
+			c:   config{numTimes: 10, session: true, auditFile: "audit.jsonl"},
+			err: errors.New("--audit-log needs a --suppress list to audit"),
+		},
+		{
+			c:   config{numTimes: 10, matchMin: 1.5},
+			err: errors.New("match threshold must be between 0 and 1"),
+		},
+		{
+			c:   config{numTimes: 10, filterMode: "drop"},
+			err: errors.New(`unknown filter mode "drop", expected reject or mask`),
+		},
+		{
+			c:   config{forever: true, interval: time.Second},
+			err: nil,
+		},
+		{
+			c:   config{forever: true, stdinBatch: true},
+			err: errors.New("--forever cannot be used with --session or --stdin-batch"),
+		},
+		{
+			c:   config{forever: true, reportFile: "run.json"},
+			err: errors.New("--report cannot be used with --forever, the run has no end to report on"),
+		},
+		{
+			c:   config{numTimes: 10, interval: time.Second},
+			err: nil,
+		},
+		{
+			c:   config{numTimes: 1, session: true, inputTimeout: time.Second},
+			err: errors.New("--input-timeout cannot be used with --session or --stdin-batch"),
+		},
+		{
+			c:   config{numTimes: 1, maxRetries: -1},
+			err: errors.New("--max-retries must not be negative"),
+		},
+		{
+			c: config{numTimes: 1, defaultName: "Guest"},
+		},
+		{
+			c:   config{numTimes: 10, interval: -time.Second},
+			err: errors.New("--interval must not be negative"),
+		},
 	}
 
 	for _, tc := range tests {
@@ -129,7 +506,7 @@ func TestRunCmd(t *testing.T) {
 	}{
 		{
 			c:      config{printUsage: true},
-			output: usageString,
+			output: renderUsage(os.Args[0], usageText, catalogs[defaultLang]),
 		},
 		{
 			c:      config{numTimes: 5},
@@ -137,11 +514,49 @@ func TestRunCmd(t *testing.T) {
 			output: strings.Repeat("Your name please? Press the return key when done.\n", 1),
 			err:    errors.New("you didn't enter your name"),
 		},
+		{
+			c:      config{numTimes: 2, maxRetries: 2},
+			input:  "\n\nBenny\n",
+			output: strings.Repeat("Your name please? Press the return key when done.\n", 3) + strings.Repeat("Nice to meet you Benny\n", 2),
+		},
+		{
+			c:      config{numTimes: 2, maxRetries: 2},
+			input:  "\n\n\nBenny\n",
+			output: strings.Repeat("Your name please? Press the return key when done.\n", 3),
+			err:    errors.New("you didn't enter your name"),
+		},
+		{
+			c:      config{numTimes: 2, maxRetries: 2},
+			input:  "\n",
+			output: strings.Repeat("Your name please? Press the return key when done.\n", 2),
+			err:    errors.New("you didn't enter your name"),
+		},
 		{
 			c:      config{numTimes: 5},
 			input:  "Benny Engstrom",
 			output: "Your name please? Press the return key when done.\n" + strings.Repeat("Nice to meet you Benny Engstrom\n", 5),
 		},
+		{
+			c:      config{numTimes: 2, name: "Benny"},
+			input:  "ignored",
+			output: strings.Repeat("Nice to meet you Benny\n", 2),
+		},
+		{
+			c:      config{numTimes: 1, name: "Shit Head", filterMode: "reject"},
+			output: "",
+			err:    errors.New("that name is not allowed"),
+		},
+		{
+			c:      config{numTimes: 2, filterMode: "mask"},
+			input:  "Shit Head",
+			output: "Your name please? Press the return key when done.\n" + strings.Repeat("Nice to meet you **** Head\n", 2),
+		},
+		{
+			c:      config{numTimes: 2, filterMode: "reject"},
+			input:  "Shit Head",
+			output: "Your name please? Press the return key when done.\n",
+			err:    errors.New("that name is not allowed"),
+		},
 	}
 
 	// To mimic the standard output, we create an empty Buffer object that implements the `Writer` interface using `new(bytes.Buffer)`
@@ -152,7 +567,7 @@ func TestRunCmd(t *testing.T) {
 		rd := strings.NewReader(tc.input)
 		// When the getName() function is called with `io.Reader r` scanner.Text() will return the string in tc.input
 
-		err := runCmd(rd, byteBuf, tc.c)
+		err := runCmd(context.Background(), rd, byteBuf, tc.c)
 
 		if err != nil && tc.err == nil {
 			t.Fatalf("expected nil error, got: %v\n", err)
@@ -171,3 +586,256 @@ func TestRunCmd(t *testing.T) {
 		byteBuf.Reset()
 	}
 }
+
+func TestGetNameTimeout(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	out := new(bytes.Buffer)
+	m := catalogs[defaultLang]
+	_, err := getName(bufio.NewScanner(pr), out, m, 20*time.Millisecond, 0)
+	if err != errInputTimeout {
+		t.Errorf("expected error: %v, got: %v\n", errInputTimeout, err)
+	}
+	if out.String() != m.prompt+"\n" {
+		t.Errorf("expected the prompt, got: %q\n", out.String())
+	}
+
+	// a name in time is read as usual
+	name, err := getName(bufio.NewScanner(strings.NewReader("Benny\n")), out, m, time.Second, 0)
+	if err != nil || name != "Benny" {
+		t.Errorf("expected Benny, got: %q, %v\n", name, err)
+	}
+}
+
+func TestInputTimeoutDefaultName(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	out := new(bytes.Buffer)
+	c := withDefaults(config{numTimes: 1, inputTimeout: 20 * time.Millisecond, defaultName: "Guest"})
+	if err := runCmd(context.Background(), pr, out, c); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if !strings.HasSuffix(out.String(), "Nice to meet you Guest\n") {
+		t.Errorf("expected the default name to be greeted, got: %q\n", out.String())
+	}
+
+	// an *os.File, so nothing copies from it and it is left open
+	stdin, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	defer stdinW.Close()
+	cmd := exec.Command("./"+binaryName, "--input-timeout", "20ms", "1")
+	cmd.Stdin = stdin
+	output, _ := cmd.CombinedOutput()
+	if cmd.ProcessState.ExitCode() != exitInputTimeout || !strings.HasSuffix(string(output), "input timed out\n") {
+		t.Errorf("expected exit code %d and the timeout, got %d: %q\n", exitInputTimeout, cmd.ProcessState.ExitCode(), output)
+	}
+}
+
+func TestDefaultNameFallback(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skip("no OS user to fall back to")
+	}
+	configDir := t.TempDir()
+	configFile := filepath.Join(configDir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("default-name: Config\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args     []string
+		env      []string
+		input    string
+		expected string
+	}{
+		{args: []string{"--default-name", "Flag"}, env: []string{"NAME_CLI_DEFAULT_NAME=Env", "NAME_CLI_CONFIG=" + configFile}, expected: "Flag"},
+		{env: []string{"NAME_CLI_DEFAULT_NAME=Env", "NAME_CLI_CONFIG=" + configFile}, expected: "Env"},
+		{env: []string{"NAME_CLI_CONFIG=" + configFile}, expected: "Config"},
+		{expected: u.Username},
+		// an empty line is no name either
+		{args: []string{"--max-retries", "0"}, input: "\n", expected: u.Username},
+		// a name on stdin still wins
+		{args: []string{"--default-name", "Flag"}, input: "Benny\n", expected: "Benny"},
+	}
+	for _, tc := range tests {
+		cmd := exec.Command("./"+binaryName, append(tc.args, "1")...)
+		cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME="+t.TempDir())
+		cmd.Env = append(cmd.Env, tc.env...)
+		cmd.Stdin = strings.NewReader(tc.input)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Errorf("%v %v: expected nil error, got: %v\n%s", tc.env, tc.args, err, out)
+			continue
+		}
+		if !strings.HasSuffix(string(out), "Nice to meet you "+tc.expected+"\n") {
+			t.Errorf("%v %v: expected %v to be greeted, got: %q\n", tc.env, tc.args, tc.expected, out)
+		}
+	}
+
+	// without nameFallback, which is only set when stdin isn't a terminal,
+	// there is no fallback
+	c := withDefaults(config{numTimes: 1, defaultName: "Guest"})
+	err = runCmd(context.Background(), strings.NewReader(""), new(bytes.Buffer), c)
+	if err != ErrEmptyName {
+		t.Errorf("expected error: %v, got: %v\n", ErrEmptyName, err)
+	}
+}
+
+func TestPipedPrompt(t *testing.T) {
+	prompt := catalogs[defaultLang].prompt + "\n"
+	tests := []struct {
+		args   []string
+		output string
+	}{
+		// a buffer isn't a terminal, so the name is read without asking
+		{args: []string{"1"}, output: "Nice to meet you Benny\n"},
+		{args: []string{"--no-prompt", "1"}, output: "Nice to meet you Benny\n"},
+		{args: []string{"--force-prompt", "1"}, output: prompt + "Nice to meet you Benny\n"},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		if _, err := runCommand(context.Background(), strings.NewReader("Benny\n"), out, c); err != nil {
+			t.Fatalf("%v: expected nil error, got: %v\n", tc.args, err)
+		}
+		if out.String() != tc.output {
+			t.Errorf("%v: expected %q, got: %q\n", tc.args, tc.output, out.String())
+		}
+	}
+
+	// runCmd leaves the choice to its caller
+	out := new(bytes.Buffer)
+	c := withDefaults(config{numTimes: 1, noPrompt: true, maxRetries: 1})
+	if err := runCmd(context.Background(), strings.NewReader("\nBenny\n"), out, c); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Nice to meet you Benny\n" {
+		t.Errorf("expected no prompt, also when asking again, got: %q\n", out.String())
+	}
+
+	err := validateArgs(config{numTimes: 1, noPrompt: true, forcePrompt: true})
+	if err == nil || err.Error() != "--no-prompt and --force-prompt cannot be used together" {
+		t.Errorf("expected the flags to conflict, got: %v\n", err)
+	}
+}
+
+func TestGreetUserInterval(t *testing.T) {
+	out := new(bytes.Buffer)
+	start := time.Now()
+	if err := greetUser(context.Background(), config{numTimes: 3, interval: 20 * time.Millisecond}, "Benny", out); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if took := time.Since(start); took < 40*time.Millisecond {
+		t.Errorf("expected two 20ms waits between three greetings, took: %v\n", took)
+	}
+	if out.String() != strings.Repeat("Nice to meet you Benny\n", 3) {
+		t.Errorf("expected three greetings, got: %q\n", out.String())
+	}
+
+	// interrupted while waiting, the greetings so far are kept
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out.Reset()
+	time.AfterFunc(30*time.Millisecond, cancel)
+	err := greetUser(ctx, config{numTimes: 100, interval: 20 * time.Millisecond}, "Benny", out)
+	if err != errInterrupted {
+		t.Errorf("expected error: %v, got: %v\n", errInterrupted, err)
+	}
+	if n := strings.Count(out.String(), "\n"); n < 1 || n > 3 {
+		t.Errorf("expected the greetings up to the interrupt, got: %q\n", out.String())
+	}
+}
+
+// TestGreetUserConcurrent runs greetUser with different configs from many
+// goroutines at once, as serve does, run it with -race.
+func TestGreetUserConcurrent(t *testing.T) {
+	configs := []config{
+		{numTimes: 3},
+		{numTimes: 2, lang: "de"},
+		{numTimes: 4, output: outputJSON},
+		{numTimes: 2, template: "{{.Index}}/{{.Count}} {{.Name}}"},
+		{numTimes: 5000},
+	}
+	expected := make([]string, len(configs))
+	for i, c := range configs {
+		var b bytes.Buffer
+		if err := greetUser(context.Background(), withDefaults(c), "Benny", &b); err != nil {
+			t.Fatal(err)
+		}
+		expected[i] = b.String()
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 100)
+	for n := 0; n < 100; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			i := n % len(configs)
+			var b bytes.Buffer
+			if err := greetUser(context.Background(), withDefaults(configs[i]), "Benny", &b); err != nil || b.String() != expected[i] {
+				errs <- fmt.Sprintf("config %d: expected %d bytes, got %d: %v", i, len(expected[i]), b.Len(), err)
+			}
+		}(n)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestGreetUserLargeCount(t *testing.T) {
+	// enough repetitions to fill the write buffer several times over
+	numTimes := 3*greeter.BufSize/len("Nice to meet you Benny\n") + 7
+	byteBuf := new(bytes.Buffer)
+	greetUser(context.Background(), config{numTimes: numTimes}, "Benny", byteBuf)
+
+	expected := strings.Repeat("Nice to meet you Benny\n", numTimes)
+	if byteBuf.String() != expected {
+		t.Errorf("expected %v greetings, got output of length: %v\n", numTimes, byteBuf.Len())
+	}
+}
+
+// TestGreetUserConstantMemory makes sure a large count is streamed, not
+// built up in memory: a million greetings are 23MB of output.
+func TestGreetUserConstantMemory(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if err := greetUser(context.Background(), config{numTimes: 1000000}, "Benny", io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 4*greeter.BufSize {
+		t.Errorf("expected at most %v bytes to be allocated, got: %v\n", 4*greeter.BufSize, allocated)
+	}
+}
+
+func BenchmarkGreetUser(b *testing.B) {
+	c := config{numTimes: 100000}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		greetUser(context.Background(), c, "Benny Engstrom", io.Discard)
+	}
+}
+
+// BenchmarkGreetUserCounts shows memory use staying the same, B/op, as
+// the count grows.
+func BenchmarkGreetUserCounts(b *testing.B) {
+	for _, numTimes := range []int{1000, 100000, 10000000} {
+		b.Run(strconv.Itoa(numTimes), func(b *testing.B) {
+			c := config{numTimes: numTimes}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				greetUser(context.Background(), c, "Benny Engstrom", io.Discard)
+			}
+		})
+	}
+}
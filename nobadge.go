@@ -0,0 +1,22 @@
+//go:build nobadge
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+)
+
+// Built with -tags nobadge, badge is left out of commands. These stand in
+// for it so the rest still compiles.
+
+const hasBadge = false
+
+var errNoBadge = errors.New("badge is not in this build, it was built with -tags nobadge")
+
+func newBadgeFlagSet(c *config) *flag.FlagSet      { return newHelpFlagSet(c) }
+func badgeDefaults() config                        { return config{command: "badge"} }
+func parseBadgeArgs(args []string) (config, error) { return config{}, errNoBadge }
+func validateBadgeArgs(c config) error             { return errNoBadge }
+func runBadge(w io.Writer, c config) error         { return errNoBadge }
@@ -0,0 +1,29 @@
+//go:build noserve
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+)
+
+// Built with -tags noserve, serve and loadtest are left out of
+// commands. These stand in for them so the rest still compiles.
+
+const hasServe = false
+
+var errNoServe = errors.New("serve is not in this build, it was built with -tags noserve")
+
+func newServeFlagSet(c *config) *flag.FlagSet    { return newHelpFlagSet(c) }
+func newLoadtestFlagSet(c *config) *flag.FlagSet { return newHelpFlagSet(c) }
+func serveDefaults() config                      { return config{command: "serve"} }
+func loadtestDefaults() config                   { return config{command: "loadtest"} }
+
+func parseServeArgs(args []string) (config, error)                 { return config{}, errNoServe }
+func parseLoadtestArgs(args []string) (config, error)              { return config{}, errNoServe }
+func validateServeArgs(c config) error                             { return errNoServe }
+func validateLoadtestArgs(c config) error                          { return errNoServe }
+func runServe(ctx context.Context, w io.Writer, c config) error    { return errNoServe }
+func runLoadtest(ctx context.Context, w io.Writer, c config) error { return errNoServe }
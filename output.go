@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// encoder formats one repetition of a greeting as a line of output, for
+// --output.
+type encoder interface {
+	appendLine(dst []byte, name, greeting string, index int) []byte
+}
+
+var encoders = map[string]encoder{
+	outputText: textEncoder{},
+	outputJSON: jsonEncoder{},
+}
+
+func outputNames() []string {
+	names := []string{}
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// encoder returns the encoder for --output, plain text by default.
+func (c config) encoder() encoder {
+	if e, ok := encoders[c.output]; ok {
+		return e
+	}
+	return textEncoder{}
+}
+
+// textEncoder writes the greeting as is.
+type textEncoder struct{}
+
+func (textEncoder) appendLine(dst []byte, name, greeting string, index int) []byte {
+	dst = append(dst, greeting...)
+	return append(dst, '\n')
+}
+
+// greetingRecord is a line of --output json.
+type greetingRecord struct {
+	Name     string `json:"name"`
+	Greeting string `json:"greeting"`
+	Index    int    `json:"index"` // from 1 to the count
+}
+
+// jsonEncoder writes a greetingRecord per line, which makes the output
+// newline delimited JSON.
+type jsonEncoder struct{}
+
+func (jsonEncoder) appendLine(dst []byte, name, greeting string, index int) []byte {
+	// a struct of strings and ints always marshals
+	data, _ := json.Marshal(greetingRecord{Name: name, Greeting: greeting, Index: index})
+	dst = append(dst, data...)
+	return append(dst, '\n')
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jordanengstrom/name-cli-app.git/pkg/greeter"
+)
+
+func TestEncoders(t *testing.T) {
+	tests := []struct {
+		output string
+		name   string
+		line   string
+		index  int
+		want   string
+	}{
+		{output: outputText, name: "Benny", line: "Nice to meet you Benny", index: 1, want: "Nice to meet you Benny\n"},
+		{output: outputJSON, name: "Benny", line: "Nice to meet you Benny", index: 1, want: `{"name":"Benny","greeting":"Nice to meet you Benny","index":1}` + "\n"},
+		{output: outputJSON, name: `Bo "the" Co`, line: "Hi Bo \"the\" Co", index: 12, want: `{"name":"Bo \"the\" Co","greeting":"Hi Bo \"the\" Co","index":12}` + "\n"},
+	}
+	for _, tc := range tests {
+		got := string(encoders[tc.output].appendLine([]byte("before\n"), tc.name, tc.line, tc.index))
+		if got != "before\n"+tc.want {
+			t.Errorf("%v: expected %q, got: %q\n", tc.output, "before\n"+tc.want, got)
+		}
+	}
+}
+
+func TestGreetUserJSON(t *testing.T) {
+	tests := []struct {
+		c       config
+		records []greetingRecord
+	}{
+		{
+			c: config{numTimes: 2, output: outputJSON},
+			records: []greetingRecord{
+				{Name: "Benny", Greeting: "Nice to meet you Benny", Index: 1},
+				{Name: "Benny", Greeting: "Nice to meet you Benny", Index: 2},
+			},
+		},
+		{
+			c: config{numTimes: 2, output: outputJSON, template: "{{.Index}}. Hi {{.Name}}"},
+			records: []greetingRecord{
+				{Name: "Benny", Greeting: "1. Hi Benny", Index: 1},
+				{Name: "Benny", Greeting: "2. Hi Benny", Index: 2},
+			},
+		},
+		{
+			c: config{numTimes: 1, output: outputJSON, lang: "sv"},
+			records: []greetingRecord{
+				{Name: "Benny", Greeting: "Trevligt att träffas, Benny", Index: 1},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		out := new(bytes.Buffer)
+		if err := greetUser(context.Background(), tc.c, "Benny", out); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		var records []greetingRecord
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			var r greetingRecord
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				t.Fatalf("expected a json object per line, got %q: %v\n", scanner.Text(), err)
+			}
+			records = append(records, r)
+		}
+		if len(records) != len(tc.records) {
+			t.Fatalf("expected %v records, got: %v\n", len(tc.records), records)
+		}
+		for i := range records {
+			if records[i] != tc.records[i] {
+				t.Errorf("expected record %v to be: %+v, got: %+v\n", i, tc.records[i], records[i])
+			}
+		}
+	}
+}
+
+func TestGreetUserJSONLargeCount(t *testing.T) {
+	numTimes := 2*greeter.BufSize/len(`{"name":"Benny","greeting":"Nice to meet you Benny","index":1}`) + 1
+	out := new(bytes.Buffer)
+	if err := greetUser(context.Background(), config{numTimes: numTimes, output: outputJSON}, "Benny", out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != numTimes {
+		t.Fatalf("expected %v lines, got: %v\n", numTimes, len(lines))
+	}
+	last := `{"name":"Benny","greeting":"Nice to meet you Benny","index":` + strconv.Itoa(numTimes) + "}"
+	if lines[numTimes-1] != last {
+		t.Errorf("expected the last line to be: %q, got: %q\n", last, lines[numTimes-1])
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	tests := []struct {
+		c   config
+		err error
+	}{
+		{c: config{numTimes: 1, output: outputJSON}},
+		{c: config{numTimes: 1, output: "xml"}, err: errors.New(`unknown output format "xml", expected one of: json, text`)},
+		{c: config{numTimes: 1, output: outputJSON, pronounce: true, allowlistFile: "guests.txt"}, err: errors.New("--show-pronunciation cannot be used with --output json")},
+	}
+	for _, tc := range tests {
+		err := validateArgs(withDefaults(tc.c))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Errorf("expected error to be: %v, got: %v\n", tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Errorf("expected nil error, got: %v\n", err)
+		}
+	}
+}
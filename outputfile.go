@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// outputFile is where greetings go with --output-file. A failed run leaves
+// the file as it was: greetings are written to a temporary file next to
+// it which only replaces it once the run succeeded, or with --append
+// straight to the file, which is cut back to its old size on failure.
+// A run stopped with Ctrl+C isn't a failure, nor is a batch in which only
+// some names were rejected: what they wrote is kept.
+type outputFile struct {
+	f          *os.File
+	path       string
+	appendMode bool
+	size       int64 // of the file before the run, for --append
+}
+
+func createOutputFile(path string, appendMode bool) (*outputFile, error) {
+	o := &outputFile{path: path, appendMode: appendMode}
+	var err error
+	if appendMode {
+		o.f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err == nil {
+			var fi os.FileInfo
+			if fi, err = o.f.Stat(); err == nil {
+				o.size = fi.Size()
+			} else {
+				o.f.Close()
+			}
+		}
+	} else {
+		// in the same directory, so the rename can't cross file systems
+		o.f, err = os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open output file: %w", err)
+	}
+	return o, nil
+}
+
+func (o *outputFile) Write(p []byte) (int, error) {
+	return o.f.Write(p)
+}
+
+// finish keeps what was written if runErr is nil or keepsOutput, and
+// undoes it otherwise.
+func (o *outputFile) finish(runErr error) error {
+	if keepsOutput(runErr) {
+		// the greeter wrote out the whole lines it had before returning,
+		// make sure they reach the disk before the rename
+		if err := o.f.Sync(); err != nil {
+			o.f.Close()
+			if !o.appendMode {
+				os.Remove(o.f.Name())
+			}
+			return fmt.Errorf("could not write output file: %w", err)
+		}
+		runErr = nil
+	}
+	if o.appendMode {
+		if runErr != nil {
+			if err := o.f.Truncate(o.size); err != nil {
+				o.f.Close()
+				return fmt.Errorf("could not restore output file: %w", err)
+			}
+		}
+		if err := o.f.Close(); err != nil {
+			return fmt.Errorf("could not write output file: %w", err)
+		}
+		return nil
+	}
+
+	tmp := o.f.Name()
+	if runErr != nil {
+		o.f.Close()
+		os.Remove(tmp)
+		return nil
+	}
+	// CreateTemp makes the file private, give it the mode of the file it
+	// replaces or that of a new file
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(o.path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	err := o.f.Chmod(mode)
+	if cerr := o.f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, o.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("could not write output file: %w", err)
+	}
+	return nil
+}
+
+// keepsOutput reports whether the greetings written by a run that failed
+// with err are kept: those before Ctrl+C and those of a batch that
+// greeted some names, which are in the history already.
+func keepsOutput(err error) bool {
+	var batch batchError
+	if errors.As(err, &batch) {
+		return batch.greeted > 0
+	}
+	return errors.Is(err, errInterrupted)
+}
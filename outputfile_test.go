@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputFile(t *testing.T) {
+	tests := []struct {
+		appendMode bool
+		runErr     error
+		expected   string
+	}{
+		{expected: "new\n"},
+		{runErr: errors.New("boom"), expected: "old\n"},
+		{appendMode: true, expected: "old\nnew\n"},
+		{appendMode: true, runErr: errors.New("boom"), expected: "old\n"},
+	}
+
+	for _, tc := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "greetings.txt")
+		if err := os.WriteFile(path, []byte("old\n"), 0640); err != nil {
+			t.Fatal(err)
+		}
+
+		o, err := createOutputFile(path, tc.appendMode)
+		if err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if _, err := o.Write([]byte("new\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := o.finish(tc.runErr); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.expected {
+			t.Errorf("append %v, run error %v: expected the file to contain %q, got: %q\n", tc.appendMode, tc.runErr, tc.expected, data)
+		}
+		if fi, _ := os.Stat(path); fi.Mode().Perm() != 0640 {
+			t.Errorf("expected the file to keep its mode, got: %v\n", fi.Mode())
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("expected no temporary files to be left, got: %v\n", entries)
+		}
+	}
+}
+
+func TestOutputFileUnwritable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "greetings.txt")
+	for _, appendMode := range []bool{false, true} {
+		_, err := createOutputFile(path, appendMode)
+		if err == nil || !strings.HasPrefix(err.Error(), "could not open output file: ") {
+			t.Errorf("append %v: expected an error opening %v, got: %v\n", appendMode, path, err)
+		}
+	}
+}
+
+func TestOutputFileRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greetings.txt")
+	out, err := exec.Command("./"+binaryName, "--name", "Benny", "-o", path, "2").CombinedOutput()
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n%s", err, out)
+	}
+	if len(out) != 0 {
+		t.Errorf("expected nothing on stdout, got: %q\n", out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != strings.Repeat("Nice to meet you Benny\n", 2) {
+		t.Errorf("expected the greetings in the file, got: %q\n", data)
+	}
+
+	// a run that fails half way leaves the file as it was
+	out, err = exec.Command("./"+binaryName, "--name", "Benny", "-o", path, "--fail-after", "1", "3").CombinedOutput()
+	if err == nil || string(out) != "injected failure after 1 lines of output\n" {
+		t.Errorf("expected the run to fail, got: %v: %q\n", err, out)
+	}
+	if data, _ := os.ReadFile(path); string(data) != strings.Repeat("Nice to meet you Benny\n", 2) {
+		t.Errorf("expected the file to be unchanged, got: %q\n", data)
+	}
+}
+
+// TestOutputFileInterrupted cancels a run half way, like Ctrl+C, and
+// checks the greetings written until then end up in the file.
+func TestOutputFileInterrupted(t *testing.T) {
+	for _, appendMode := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "greetings.txt")
+		if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		o, err := createOutputFile(path, appendMode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 70*time.Millisecond)
+		c := withDefaults(config{name: "Benny", numTimes: 100, interval: 20 * time.Millisecond, greetOut: o})
+		_, err = runCommand(ctx, strings.NewReader(""), io.Discard, c)
+		cancel()
+		if !errors.Is(err, errInterrupted) {
+			t.Fatalf("append %v: expected error: %v, got: %v\n", appendMode, errInterrupted, err)
+		}
+		if err := o.finish(err); err != nil {
+			t.Fatalf("append %v: expected nil error, got: %v\n", appendMode, err)
+		}
+
+		data, _ := os.ReadFile(path)
+		got := string(data)
+		if appendMode {
+			if !strings.HasPrefix(got, "old\n") {
+				t.Errorf("append %v: expected the old contents to be kept, got: %q\n", appendMode, got)
+			}
+			got = strings.TrimPrefix(got, "old\n")
+		}
+		n := strings.Count(got, "\n")
+		if n == 0 || n >= 100 || got != strings.Repeat("Nice to meet you Benny\n", n) {
+			t.Errorf("append %v: expected some whole greetings, got: %q\n", appendMode, got)
+		}
+	}
+}
+
+// TestOutputFileBatchRejected checks a batch in which a name was rejected
+// keeps the greetings of the others, which are in the history too.
+func TestOutputFileBatchRejected(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "greetings.txt")
+	o, err := createOutputFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := withDefaults(config{numTimes: 1, stdinBatch: true, filterMode: filterReject, greetOut: o})
+	_, err = runCommand(context.Background(), strings.NewReader("Ann\nfuck\nBob\n"), io.Discard, c)
+	if err == nil || err.Error() != "1 of 3 names could not be greeted" {
+		t.Fatalf("expected the batch to fail, got: %v\n", err)
+	}
+	if err := o.finish(err); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "Nice to meet you Ann\nNice to meet you Bob\n" {
+		t.Errorf("expected the greetings of Ann and Bob in the file, got: %q\n", data)
+	}
+	if entries, err := openHistory().entries(); err != nil || len(entries) != 2 {
+		t.Errorf("expected Ann and Bob in the history, got: %+v, %v\n", entries, err)
+	}
+
+	// with nobody greeted there's nothing to keep
+	o, err = createOutputFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.greetOut = o
+	_, err = runCommand(context.Background(), strings.NewReader("fuck\n"), io.Discard, c)
+	if err := o.finish(err); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "Nice to meet you Ann\nNice to meet you Bob\n" {
+		t.Errorf("expected the file to be unchanged, got: %q\n", data)
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !nobadge
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePDF(t *testing.T) {
+	var b bytes.Buffer
+	if err := writePDF(&b, 288, 216, "0 g 0 0 10 10 re f"); err != nil {
+		t.Fatal(err)
+	}
+	pdf := b.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("expected a PDF header and trailer, got:\n%s", pdf)
+	}
+
+	// every xref entry has to point at its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	if m == nil {
+		t.Fatal("expected startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(pdf[xref:], "xref\n") {
+		t.Fatalf("expected startxref to point at the xref table, got: %q", pdf[xref:xref+10])
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf[xref:], -1)
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 objects, got %d", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(pdf[off:], want) {
+			t.Errorf("expected object %d at offset %d, got: %q\n", i+1, off, pdf[off:off+10])
+		}
+	}
+	if !strings.Contains(pdf, "<< /Length 18 >>") {
+		t.Errorf("expected the content length, got:\n%s", pdf)
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{in: "Benny", out: "(Benny)"},
+		{in: `a (b) \c`, out: `(a \(b\) \\c)`},
+		{in: "Zoë 李", out: "(Zo\xeb ?)"},
+	}
+
+	for _, tc := range tests {
+		if out := pdfString(pdfText(tc.in)); out != tc.out {
+			t.Errorf("%q: expected %q, got: %q\n", tc.in, tc.out, out)
+		}
+	}
+}
+
+func TestPDFNum(t *testing.T) {
+	for in, out := range map[float64]string{288: "288", 1.5: "1.5", 0.126: "0.13", 22.857: "22.86"} {
+		if s := pdfNum(in); s != out {
+			t.Errorf("%v: expected %s, got: %s\n", in, out, s)
+		}
+	}
+}
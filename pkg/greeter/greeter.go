@@ -0,0 +1,365 @@
+// Package greeter asks for a name and greets it, the core of name-cli for
+// Go programs that want the same behavior without running the tool:
+//
+//	err := greeter.Run(ctx, os.Stdin, os.Stdout, greeter.Config{Times: 3})
+//
+// Everything around it, flags, config files, translations, the denylist
+// and the other commands, stays with the tool.
+package greeter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// DefaultPrompt asks for the name unless Config.Prompt is set.
+	DefaultPrompt = "Your name please? Press the return key when done."
+	// DefaultMessage comes before the name unless Config.Message is set.
+	DefaultMessage = "Nice to meet you"
+)
+
+// BufSize is how much output Greet collects before writing it out.
+const BufSize = 64 * 1024
+
+// interruptCheckEvery is how many greetings Greet writes between looking
+// at its context, checking every line costs more than the line.
+const interruptCheckEvery = 1024
+
+var (
+	// ErrNoName is returned when the name was left empty, also after
+	// asking again Config.MaxRetries times.
+	ErrNoName = errors.New("you didn't enter your name")
+	// ErrInputTimeout is returned when no name was entered within
+	// Config.InputTimeout.
+	ErrInputTimeout = errors.New("input timed out")
+	// ErrInterrupted is returned when ctx is done before all greetings
+	// are written.
+	ErrInterrupted = errors.New("interrupted")
+)
+
+// Greeting is what a Config.Template is executed with, once per line.
+type Greeting struct {
+	Name      string
+	Iteration Number // from 1 to Total
+	Total     Number // Config.Times
+	Time      time.Time
+}
+
+// Index is Iteration, the name templates used before it.
+func (g Greeting) Index() Number { return g.Iteration }
+
+// Count is Total, the name templates used before it.
+func (g Greeting) Count() Number { return g.Total }
+
+// Number is a count in a Greeting. Templates print it with
+// Config.FormatNumber, as {{.Total}}, compare it with another, as in
+// {{if eq .Iteration .Total}}, and with a plain number by its Value, as
+// in {{if eq .Total.Value 1}}.
+type Number struct {
+	Value int
+	// a pointer, so Numbers of the same Greet compare equal
+	format *func(int) string
+}
+
+func (n Number) String() string {
+	if n.format == nil || *n.format == nil {
+		return strconv.Itoa(n.Value)
+	}
+	return (*n.format)(n.Value)
+}
+
+// Num makes the num function of templates out of format, taking a Number
+// as well as a plain int.
+func Num(format func(int) string) func(interface{}) (string, error) {
+	return func(v interface{}) (string, error) {
+		switch n := v.(type) {
+		case int:
+			return format(n), nil
+		case Number:
+			return format(n.Value), nil
+		}
+		return "", fmt.Errorf("num: expected a number, got %T", v)
+	}
+}
+
+// Config says who to greet and how. The zero value asks for the name and
+// greets nobody, set at least Times or Forever.
+type Config struct {
+	Name     string        // greeted without asking if set
+	Times    int           // how many times to greet
+	Forever  bool          // greet until ctx is done instead of Times
+	Interval time.Duration // between greetings, every line is written out as soon as it's formatted
+
+	Prompt   string             // DefaultPrompt if empty
+	Message  string             // put before the name, DefaultMessage if empty
+	Template *template.Template // executed with a Greeting per line instead of Message, see ParseTemplate
+
+	// FormatNumber, if set, is how a Template prints the Numbers of a
+	// Greeting, plainly as 1000 otherwise.
+	FormatNumber func(n int) string
+
+	// Encode, if set, appends a line of output for the greeting to dst,
+	// with the newline. Plain text is written otherwise.
+	Encode func(dst []byte, name, greeting string, index int) []byte
+
+	MaxRetries   int           // how many more times to ask after an empty name
+	InputTimeout time.Duration // how long to wait for the name, 0 for ever
+	DefaultName  string        // greeted if the name isn't entered in time
+}
+
+// Validate reports the first problem with c that would stop Run.
+func (c Config) Validate() error {
+	switch {
+	case c.Forever && c.Times != 0:
+		return errors.New("Times cannot be set with Forever")
+	case c.Times <= 0 && !c.Forever:
+		return errors.New("Times must be greater than 0")
+	case c.Interval < 0:
+		return errors.New("Interval must not be negative")
+	case c.MaxRetries < 0:
+		return errors.New("MaxRetries must not be negative")
+	case c.InputTimeout < 0:
+		return errors.New("InputTimeout must not be negative")
+	case c.Name != "" && strings.TrimSpace(c.Name) == "":
+		return ErrNoName
+	}
+	return nil
+}
+
+// ParseTemplate parses a greeting template and tries it out on a sample
+// Greeting, so mistakes like a misspelled field are reported before
+// anyone is asked for their name. Besides the fields of Greeting the
+// template can use num, as in {{num .Total.Value}}, and date, as in
+// {{date .Time}}. They format plainly, as 1000 and 2006-01-02, callers
+// greeting in a language of their own replace them with Funcs and set
+// Config.FormatNumber to match.
+func ParseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("greeting").Funcs(template.FuncMap{
+		"num":  Num(strconv.Itoa),
+		"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(io.Discard, Greeting{Name: "Benny", Iteration: Number{Value: 1}, Total: Number{Value: 1}, Time: time.Now()}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Run asks for the name on r unless c.Name is set, greeting c.DefaultName
+// if it isn't entered in time, and greets it on w.
+func Run(ctx context.Context, r io.Reader, w io.Writer, c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	name := c.Name
+	if name == "" {
+		var err error
+		name, err = AskName(bufio.NewScanner(r), w, c.Prompt, c.InputTimeout, c.MaxRetries)
+		if err == ErrInputTimeout && c.DefaultName != "" {
+			name, err = c.DefaultName, nil
+		}
+		if err == io.EOF {
+			err = ErrNoName
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return Greet(ctx, w, c, name)
+}
+
+// AskName prints prompt, DefaultPrompt if empty, and reads a line from
+// scanner as the name. After an empty entry it asks again, up to retries
+// times, before giving up with ErrNoName. It returns io.EOF once there is
+// no more input to read, and ErrInputTimeout if timeout is set and no line
+// came in within it. The read is left running then, so scanner must not
+// be used again.
+func AskName(scanner *bufio.Scanner, w io.Writer, prompt string, timeout time.Duration, retries int) (string, error) {
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+	for attempt := 0; ; attempt++ {
+		name, err := readName(scanner, w, prompt, timeout)
+		if err == ErrNoName && attempt < retries {
+			continue
+		}
+		if err == io.EOF && attempt > 0 {
+			// the input ran out while asking again
+			return "", ErrNoName
+		}
+		return name, err
+	}
+}
+
+// readName prints the prompt and reads one line as the name.
+func readName(scanner *bufio.Scanner, w io.Writer, prompt string, timeout time.Duration) (string, error) {
+	fmt.Fprintln(w, prompt)
+	scanned := true
+	if timeout > 0 {
+		done := make(chan bool, 1)
+		go func() { done <- scanner.Scan() }()
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case scanned = <-done:
+		case <-timer.C:
+			return "", ErrInputTimeout
+		}
+	} else {
+		scanned = scanner.Scan()
+	}
+	if !scanned {
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+
+	name := scanner.Text()
+	if len(name) == 0 {
+		return "", ErrNoName
+	}
+
+	return name, nil
+}
+
+// Greet streams the greeting c.Times times through a bufio.Writer of its
+// own, so memory use doesn't depend on the count and large counts cost
+// one write per BufSize of output. Nothing is shared between calls, so
+// any number can run at once, each on its own w. The plain text greeting
+// is formatted once and copied in, anything else is formatted line by
+// line by c.Encode, straight into the writer's buffer. Templates are
+// executed for every line as the index and time change. Once ctx is done
+// it stops after a whole line, writes out what it has and returns
+// ErrInterrupted, except with c.Forever where that is how the run ends
+// and it returns nil. With a c.Interval or c.Forever every line is
+// written out as soon as it's formatted, c.Interval apart.
+func Greet(ctx context.Context, w io.Writer, c Config, name string) error {
+	_, err := GreetCount(ctx, w, c, name)
+	return err
+}
+
+// GreetCount is Greet returning how many greetings it wrote, which with
+// c.Forever or an interruption isn't known beforehand. After a write
+// error it can include greetings that were still buffered.
+func GreetCount(ctx context.Context, w io.Writer, c Config, name string) (int, error) {
+	message := c.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+	msg := message + " " + name
+
+	// small runs, like most requests to serve, don't need the whole buffer
+	size := BufSize
+	if n := c.Times * (len(msg) + 1); !c.Forever && n > 0 && n < size {
+		size = n
+	}
+	bw := bufio.NewWriterSize(w, size)
+
+	paced := c.Forever || c.Interval > 0
+	if c.Encode == nil && c.Template == nil && !paced {
+		// the common case, one copy per line
+		msg += "\n"
+		for i := 0; i < c.Times; i++ {
+			if i%interruptCheckEvery == 0 && ctx.Err() != nil {
+				return i, interruptedFlush(bw)
+			}
+			if _, err := bw.WriteString(msg); err != nil {
+				return i, err
+			}
+		}
+		return c.Times, bw.Flush()
+	}
+
+	encode := c.Encode
+	if encode == nil {
+		encode = appendText
+	}
+	var executed strings.Builder
+	writeLine := func(i int) error {
+		line := msg
+		if c.Template != nil {
+			executed.Reset()
+			g := Greeting{
+				Name:      name,
+				Iteration: Number{Value: i, format: &c.FormatNumber},
+				Total:     Number{Value: c.Times, format: &c.FormatNumber},
+				Time:      time.Now(),
+			}
+			if err := c.Template.Execute(&executed, g); err != nil {
+				return err
+			}
+			line = executed.String()
+		}
+		// make room first so the line is encoded in place, an encoded line
+		// longer than that is copied in by Write
+		if bw.Available() < len(line)+1 && bw.Buffered() > 0 {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+		}
+		_, err := bw.Write(encode(bw.AvailableBuffer(), name, line, i))
+		return err
+	}
+
+	// lines 1 to i-1 are written at the top of each round
+	i := 1
+	for ; c.Forever || i <= c.Times; i++ {
+		if paced && i > 1 {
+			if wait(ctx, c.Interval) != nil {
+				if c.Forever {
+					return i - 1, nil
+				}
+				return i - 1, ErrInterrupted
+			}
+		} else if i%interruptCheckEvery == 0 && ctx.Err() != nil {
+			return i - 1, interruptedFlush(bw)
+		}
+		if err := writeLine(i); err != nil {
+			return i - 1, err
+		}
+		if paced {
+			if err := bw.Flush(); err != nil {
+				return i - 1, err
+			}
+		}
+	}
+	return i - 1, bw.Flush()
+}
+
+// appendText writes the greeting as is.
+func appendText(dst []byte, name, greeting string, index int) []byte {
+	dst = append(dst, greeting...)
+	return append(dst, '\n')
+}
+
+// wait returns after d, or with ctx's error once it is done.
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// interruptedFlush writes out the greetings Greet collected before it was
+// interrupted.
+func interruptedFlush(bw *bufio.Writer) error {
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return ErrInterrupted
+}
@@ -0,0 +1,219 @@
+package greeter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	prompt := DefaultPrompt + "\n"
+	tests := []struct {
+		c      Config
+		input  string
+		output string
+		err    error
+	}{
+		{
+			c:      Config{Times: 2},
+			input:  "Benny\n",
+			output: prompt + strings.Repeat("Nice to meet you Benny\n", 2),
+		},
+		{
+			c:      Config{Times: 1, Name: "Benny"},
+			input:  "ignored\n",
+			output: "Nice to meet you Benny\n",
+		},
+		{
+			c:      Config{Times: 1, Prompt: "Wie heißt du?", Message: "Schön dich kennenzulernen"},
+			input:  "Benny\n",
+			output: "Wie heißt du?\nSchön dich kennenzulernen Benny\n",
+		},
+		{
+			c:      Config{Times: 1, MaxRetries: 2},
+			input:  "\n\nBenny\n",
+			output: strings.Repeat(prompt, 3) + "Nice to meet you Benny\n",
+		},
+		{
+			c:      Config{Times: 1, MaxRetries: 1},
+			input:  "\n\nBenny\n",
+			output: strings.Repeat(prompt, 2),
+			err:    ErrNoName,
+		},
+		{
+			c:      Config{Times: 1},
+			input:  "",
+			output: prompt,
+			err:    ErrNoName,
+		},
+		{
+			c:   Config{},
+			err: errors.New("Times must be greater than 0"),
+		},
+	}
+
+	for _, tc := range tests {
+		out := new(bytes.Buffer)
+		err := Run(context.Background(), strings.NewReader(tc.input), out, tc.c)
+		if (err == nil) != (tc.err == nil) || (err != nil && err.Error() != tc.err.Error()) {
+			t.Errorf("%+v: expected error: %v, got: %v\n", tc.c, tc.err, err)
+		}
+		if out.String() != tc.output {
+			t.Errorf("%+v: expected output: %q, got: %q\n", tc.c, tc.output, out.String())
+		}
+	}
+}
+
+func TestRunDefaultName(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	out := new(bytes.Buffer)
+	c := Config{Times: 1, InputTimeout: 20 * time.Millisecond, DefaultName: "Guest"}
+	if err := Run(context.Background(), pr, out, c); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if out.String() != DefaultPrompt+"\nNice to meet you Guest\n" {
+		t.Errorf("expected the default name to be greeted, got: %q\n", out.String())
+	}
+
+	c.DefaultName = ""
+	if err := Run(context.Background(), pr, new(bytes.Buffer), c); err != ErrInputTimeout {
+		t.Errorf("expected error: %v, got: %v\n", ErrInputTimeout, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		c   Config
+		err string
+	}{
+		{c: Config{Times: 1}},
+		{c: Config{Forever: true}},
+		{c: Config{Times: 3, Forever: true}, err: "Times cannot be set with Forever"},
+		{c: Config{Times: -1}, err: "Times must be greater than 0"},
+		{c: Config{Times: 1, Interval: -time.Second}, err: "Interval must not be negative"},
+		{c: Config{Times: 1, MaxRetries: -1}, err: "MaxRetries must not be negative"},
+		{c: Config{Times: 1, InputTimeout: -time.Second}, err: "InputTimeout must not be negative"},
+		{c: Config{Times: 1, Name: "  "}, err: ErrNoName.Error()},
+	}
+	for _, tc := range tests {
+		err := tc.c.Validate()
+		if tc.err == "" && err != nil {
+			t.Errorf("%+v: expected nil error, got: %v\n", tc.c, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("%+v: expected error: %v, got: %v\n", tc.c, tc.err, err)
+		}
+	}
+}
+
+func TestGreet(t *testing.T) {
+	tmpl, err := ParseTemplate("{{.Index}}/{{.Count}} {{.Name}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamed, err := ParseTemplate("{{.Iteration}} of {{.Total}} {{.Name}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	numbered := func(dst []byte, name, greeting string, index int) []byte {
+		dst = strconv.AppendInt(dst, int64(index), 10)
+		dst = append(dst, ": "...)
+		dst = append(dst, greeting...)
+		return append(dst, '\n')
+	}
+
+	tests := []struct {
+		c        Config
+		expected string
+	}{
+		{c: Config{Times: 2}, expected: "Nice to meet you Benny\nNice to meet you Benny\n"},
+		{c: Config{Times: 2, Template: tmpl}, expected: "1/2 Benny\n2/2 Benny\n"},
+		{c: Config{Times: 2, Template: renamed}, expected: "1 of 2 Benny\n2 of 2 Benny\n"},
+		{c: Config{Times: 2, Encode: numbered}, expected: "1: Nice to meet you Benny\n2: Nice to meet you Benny\n"},
+		{c: Config{Times: 2, Template: tmpl, Encode: numbered}, expected: "1: 1/2 Benny\n2: 2/2 Benny\n"},
+	}
+	for _, tc := range tests {
+		out := new(bytes.Buffer)
+		if err := Greet(context.Background(), out, tc.c, "Benny"); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if out.String() != tc.expected {
+			t.Errorf("expected %q, got: %q\n", tc.expected, out.String())
+		}
+	}
+
+	// a long run is cut short, on a whole line, once ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out := new(bytes.Buffer)
+	if err := Greet(ctx, out, Config{Times: 10 * interruptCheckEvery}, "Benny"); err != ErrInterrupted {
+		t.Errorf("expected error: %v, got: %v\n", ErrInterrupted, err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing to be written, got: %v bytes\n", out.Len())
+	}
+
+	// and how a run with Forever ends
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	out.Reset()
+	n, err := GreetCount(ctx, out, Config{Forever: true, Interval: 20 * time.Millisecond}, "Benny")
+	if err != nil {
+		t.Errorf("expected nil error, got: %v\n", err)
+	}
+	if !strings.HasPrefix(out.String(), "Nice to meet you Benny\nNice to meet you Benny\n") {
+		t.Errorf("expected greetings every 20ms, got: %q\n", out.String())
+	}
+	if lines := strings.Count(out.String(), "\n"); n != lines {
+		t.Errorf("expected a count of %v, got: %v\n", lines, n)
+	}
+}
+
+func TestGreetCount(t *testing.T) {
+	tmpl, err := ParseTemplate("{{.Index}} {{.Name}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []Config{{Times: 3}, {Times: 3, Template: tmpl}} {
+		n, err := GreetCount(context.Background(), io.Discard, c, "Benny")
+		if err != nil || n != 3 {
+			t.Errorf("%+v: expected 3 greetings, got: %v, %v\n", c, n, err)
+		}
+		// an interrupted run counts the whole lines it wrote
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		c.Times = 10 * interruptCheckEvery
+		out := new(bytes.Buffer)
+		n, err = GreetCount(ctx, out, c, "Benny")
+		if lines := strings.Count(out.String(), "\n"); err != ErrInterrupted || n != lines {
+			t.Errorf("%+v: expected %v greetings and %v, got: %v, %v\n", c, lines, ErrInterrupted, n, err)
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	if _, err := ParseTemplate("Hi {{.Name"); err == nil {
+		t.Errorf("expected a syntax error, got nil\n")
+	}
+	if _, err := ParseTemplate("Hi {{.Nmae}}"); err == nil || !strings.Contains(err.Error(), "can't evaluate field Nmae") {
+		t.Errorf("expected the misspelled field to be reported, got: %v\n", err)
+	}
+
+	tmpl, err := ParseTemplate("{{num .Count}} on {{date .Time}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err := tmpl.Execute(out, Greeting{Total: Number{Value: 1000}, Time: time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "1000 on 2026-03-07" {
+		t.Errorf("expected the plain helpers, got: %q\n", out.String())
+	}
+}
@@ -0,0 +1,427 @@
+//go:build !nobadge
+
+package main
+
+import "fmt"
+
+// A small QR code encoder: byte mode, error correction level M, versions
+// 1 to 6. That's up to 106 bytes, plenty for the contact details on a
+// badge, and it avoids the version information blocks of larger codes.
+
+// qrBlocks is the total number of codewords, the number of error
+// correction blocks and the error correction codewords per block for
+// level M, indexed by version.
+var qrBlocks = [...]struct{ total, blocks, ecc int }{
+	1: {26, 1, 10},
+	2: {44, 1, 16},
+	3: {70, 1, 26},
+	4: {100, 2, 18},
+	5: {134, 2, 24},
+	6: {172, 4, 16},
+}
+
+const qrMaxVersion = 6
+
+// qrCode is a QR symbol, modules[y][x] is true for dark modules.
+type qrCode struct {
+	size    int
+	modules [][]bool
+	// function marks the modules of the finder, timing and alignment
+	// patterns and the format information, which masks leave alone
+	function [][]bool
+}
+
+// qrSize is the width of a version's symbol in modules.
+func qrSize(version int) int {
+	return 17 + 4*version
+}
+
+// qrDataCodewords is how many data codewords a version holds.
+func qrDataCodewords(version int) int {
+	b := qrBlocks[version]
+	return b.total - b.blocks*b.ecc
+}
+
+// qrEncode encodes data in the smallest version it fits.
+func qrEncode(data []byte) (*qrCode, error) {
+	version := 1
+	// the mode indicator and the one byte length come first
+	for ; version <= qrMaxVersion; version++ {
+		if 4+8+8*len(data) <= 8*qrDataCodewords(version) {
+			break
+		}
+	}
+	if version > qrMaxVersion {
+		return nil, fmt.Errorf("too much data for a QR code, at most %d bytes", qrDataCodewords(qrMaxVersion)-2)
+	}
+
+	codewords := qrAddECC(qrDataBits(data, qrDataCodewords(version)), version)
+
+	q := newQRCode(version)
+	q.drawCodewords(codewords)
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		// masks are their own inverse
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return q, nil
+}
+
+// qrDataBits lays out data in byte mode, padded to n codewords.
+func qrDataBits(data []byte, n int) []byte {
+	var bits []bool
+	add := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, v>>i&1 == 1)
+		}
+	}
+	add(0x4, 4)
+	add(len(data), 8)
+	for _, b := range data {
+		add(int(b), 8)
+	}
+	// terminator, then up to a whole byte
+	for i := 0; i < 4 && len(bits) < 8*n; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	out := make([]byte, 0, n)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xec); len(out) < n; pad ^= 0xec ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// qrAddECC splits the data into blocks, adds the error correction of each
+// block and interleaves them. Shorter blocks come first.
+func qrAddECC(data []byte, version int) []byte {
+	b := qrBlocks[version]
+	short := len(data) / b.blocks
+	longBlocks := len(data) % b.blocks
+	gen := rsGenerator(b.ecc)
+
+	var blocks, eccs [][]byte
+	for i, pos := 0, 0; i < b.blocks; i++ {
+		n := short
+		if i >= b.blocks-longBlocks {
+			n++
+		}
+		blocks = append(blocks, data[pos:pos+n])
+		eccs = append(eccs, rsRemainder(data[pos:pos+n], gen))
+		pos += n
+	}
+
+	out := make([]byte, 0, len(data)+b.blocks*b.ecc)
+	for i := 0; i <= short; i++ {
+		for _, blk := range blocks {
+			if i < len(blk) {
+				out = append(out, blk[i])
+			}
+		}
+	}
+	for i := 0; i < b.ecc; i++ {
+		for _, ecc := range eccs {
+			out = append(out, ecc[i])
+		}
+	}
+	return out
+}
+
+// gfMul multiplies in GF(256) with the QR polynomial x^8+x^4+x^3+x^2+1.
+func gfMul(a, b byte) byte {
+	var p byte
+	for ; b != 0; b >>= 1 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1d
+		}
+	}
+	return p
+}
+
+// rsGenerator returns the coefficients of the Reed-Solomon generator
+// polynomial of the given degree, highest first and without the leading 1.
+func rsGenerator(degree int) []byte {
+	gen := make([]byte, degree)
+	gen[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		// multiply by (x - root)
+		for j := 0; j < degree; j++ {
+			gen[j] = gfMul(gen[j], root)
+			if j+1 < degree {
+				gen[j] ^= gen[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return gen
+}
+
+func rsRemainder(data, gen []byte) []byte {
+	rem := make([]byte, len(gen))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i := range rem {
+			rem[i] ^= gfMul(gen[i], factor)
+		}
+	}
+	return rem
+}
+
+// newQRCode draws the function patterns of a version.
+func newQRCode(version int) *qrCode {
+	size := qrSize(version)
+	q := &qrCode{size: size}
+	for i := 0; i < size; i++ {
+		q.modules = append(q.modules, make([]bool, size))
+		q.function = append(q.function, make([]bool, size))
+	}
+
+	for i := 0; i < size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= size || y >= size {
+					continue
+				}
+				d := maxInt(absInt(dx), absInt(dy))
+				q.set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+	if version > 1 {
+		c := size - 7
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				q.set(c+dx, c+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+			}
+		}
+	}
+	// reserve the format information, drawn once the mask is known
+	q.drawFormat(0)
+	return q
+}
+
+// set draws a function module.
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// qrFormatBits is the 15 bit format information for level M and a mask.
+func qrFormatBits(mask int) int {
+	data := 0<<3 | mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (q *qrCode) drawFormat(mask int) {
+	bits := qrFormatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	size := q.size
+
+	// around the top left finder
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	// split between the other two
+	for i := 0; i < 8; i++ {
+		q.set(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, size-15+i, bit(i))
+	}
+	// the dark module is always there
+	q.set(8, size-8, true)
+}
+
+// drawCodewords fills the data modules in the zigzag order of the spec,
+// two columns at a time from the bottom right.
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// skip the vertical timing pattern
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if q.function[y][x] {
+					continue
+				}
+				if i < len(data)*8 {
+					q.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol by the rules of the spec, lower is easier to
+// scan.
+func (q *qrCode) penalty() int {
+	n := q.size
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+
+	p := 0
+	finder := []bool{true, false, true, true, true, false, true}
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			// runs of five or more
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += 3 + run - 5
+				}
+				run = 1
+			}
+			// finder look-alikes with four light modules on either side
+			for x := 0; x+7 <= n; x++ {
+				match := true
+				for i, dark := range finder {
+					if at(x+i, y, transpose) != dark {
+						match = false
+						break
+					}
+				}
+				if match && (q.lightRun(x-4, y, transpose) || q.lightRun(x+7, y, transpose)) {
+					p += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := q.modules[y][x]
+				if q.modules[y][x+1] == c && q.modules[y+1][x] == c && q.modules[y+1][x+1] == c {
+					p += 3
+				}
+			}
+		}
+	}
+	p += absInt(dark*100/(n*n)-50) / 5 * 10
+	return p
+}
+
+// lightRun reports whether the four modules from x are light, counting
+// modules outside the symbol as light.
+func (q *qrCode) lightRun(x, y int, transpose bool) bool {
+	for i := x; i < x+4; i++ {
+		if i < 0 || i >= q.size {
+			continue
+		}
+		dark := q.modules[y][i]
+		if transpose {
+			dark = q.modules[i][y]
+		}
+		if dark {
+			return false
+		}
+	}
+	return true
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,158 @@
+//go:build !nobadge
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// the version 1-M codewords of HELLO WORLD from the QR code spec's
+	// worked example
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if ecc := rsRemainder(data, rsGenerator(10)); !bytes.Equal(ecc, expected) {
+		t.Errorf("expected %v, got: %v\n", expected, ecc)
+	}
+}
+
+func TestQRFormatBits(t *testing.T) {
+	tests := []struct {
+		mask int
+		bits int
+	}{
+		{mask: 0, bits: 0b101010000010010},
+		{mask: 1, bits: 0b101000100100101},
+		{mask: 7, bits: 0b100101010100000},
+	}
+
+	for _, tc := range tests {
+		if bits := qrFormatBits(tc.mask); bits != tc.bits {
+			t.Errorf("mask %d: expected %015b, got: %015b\n", tc.mask, tc.bits, bits)
+		}
+	}
+}
+
+func TestQREncode(t *testing.T) {
+	tests := []struct {
+		data    string
+		version int
+	}{
+		{data: "Benny", version: 1},
+		{data: "https://example.com/", version: 2},
+		{data: "MECARD:N:Engstrom,Benny;EMAIL:benny@example.com;;", version: 4},
+		{data: strings.Repeat("x", 106), version: 6},
+	}
+
+	for _, tc := range tests {
+		q, err := qrEncode([]byte(tc.data))
+		if err != nil {
+			t.Fatalf("%q: expected nil error, got: %v\n", tc.data, err)
+		}
+		if q.size != qrSize(tc.version) {
+			t.Errorf("%q: expected version %d, got size %d\n", tc.data, tc.version, q.size)
+		}
+		if got := qrDecode(t, q, tc.version); got != tc.data {
+			t.Errorf("expected to read back %q, got: %q\n", tc.data, got)
+		}
+	}
+
+	if _, err := qrEncode(bytes.Repeat([]byte("x"), 107)); err == nil {
+		t.Error("expected an error for too much data")
+	}
+}
+
+// qrDecode reads a symbol back the way a scanner would once it found the
+// modules: format information, mask, codewords and the byte mode data.
+func qrDecode(t *testing.T, q *qrCode, version int) string {
+	t.Helper()
+	format := 0
+	for i := 0; i < 15; i++ {
+		// the second copy, along the bottom left and top right
+		var dark bool
+		if i < 8 {
+			dark = q.modules[8][q.size-1-i]
+		} else {
+			dark = q.modules[q.size-15+i][8]
+		}
+		if dark {
+			format |= 1 << i
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if qrFormatBits(m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("unreadable format information %015b", format)
+	}
+
+	// unmask a copy and read the codewords in placement order
+	d := newQRCode(version)
+	for y := range d.modules {
+		copy(d.modules[y], q.modules[y])
+	}
+	d.applyMask(mask)
+	var codewords []byte
+	var cur byte
+	n := 0
+	for right := d.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < d.size; vert++ {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = d.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if d.function[y][right-j] {
+					continue
+				}
+				cur <<= 1
+				if d.modules[y][right-j] {
+					cur |= 1
+				}
+				if n++; n%8 == 0 {
+					codewords = append(codewords, cur)
+				}
+			}
+		}
+	}
+
+	b := qrBlocks[version]
+	if len(codewords) != b.total {
+		t.Fatalf("expected %d codewords, got %d", b.total, len(codewords))
+	}
+	// undo the interleaving, all blocks of these versions have the same size
+	per := qrDataCodewords(version) / b.blocks
+	gen := rsGenerator(b.ecc)
+	var data []byte
+	for blk := 0; blk < b.blocks; blk++ {
+		var block, ecc []byte
+		for i := 0; i < per; i++ {
+			block = append(block, codewords[i*b.blocks+blk])
+		}
+		for i := 0; i < b.ecc; i++ {
+			ecc = append(ecc, codewords[per*b.blocks+i*b.blocks+blk])
+		}
+		if !bytes.Equal(rsRemainder(block, gen), ecc) {
+			t.Fatalf("block %d: error correction doesn't match", blk)
+		}
+		data = append(data, block...)
+	}
+
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("expected byte mode, got %x", data[0]>>4)
+	}
+	length := int(data[0]&0xf)<<4 | int(data[1]>>4)
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = data[1+i]<<4 | data[2+i]>>4
+	}
+	return string(out)
+}
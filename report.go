@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// reportConfig is the resolved configuration of the run. Options has
+// every option by name, with where its value came from as in --explain.
+type reportConfig struct {
+	NumTimes int                     `json:"num_times"`
+	Options  map[string]reportOption `json:"options,omitempty"`
+}
+
+type reportOption struct {
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// runReport is what gets written by --report once the run is over, so
+// pipelines can inspect a run without scraping stdout.
+type runReport struct {
+	SchemaVersion int          `json:"schema_version"`
+	Config        reportConfig `json:"config"`
+	Visitors      int          `json:"visitors"`
+	Greetings     int          `json:"greetings"`
+	StartedAt     time.Time    `json:"started_at"`
+	FinishedAt    time.Time    `json:"finished_at"`
+	DurationMs    int64        `json:"duration_ms"`
+	Success       bool         `json:"success"`
+	Error         string       `json:"error,omitempty"`
+}
+
+func newRunReport() *runReport {
+	return &runReport{SchemaVersion: schemaVersion, StartedAt: time.Now()}
+}
+
+// finish fills in the remaining fields once the run is over. visitors is
+// the number of people greeted, which is only ever more than one in
+// session mode, and greetings the number of greetings written, which a
+// failed or interrupted run counts too.
+func (r *runReport) finish(c config, visitors, greetings int, err error) {
+	r.FinishedAt = time.Now()
+	r.DurationMs = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+	r.Config = reportConfig{NumTimes: c.numTimes, Options: map[string]reportOption{}}
+	for _, o := range resolvedOptions(c) {
+		r.Config.Options[o.name] = reportOption{Value: o.value, Origin: o.origin}
+	}
+	r.Visitors, r.Greetings = visitors, greetings
+	if err != nil {
+		r.Error = err.Error()
+		return
+	}
+	r.Success = true
+}
+
+func writeReport(path string, r *runReport) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("could not write report: %w", err)
+	}
+	return nil
+}
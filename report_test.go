@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	tests := []struct {
+		c         config
+		visitors  int
+		greeted   int
+		err       error
+		greetings int
+		success   bool
+		errMsg    string
+	}{
+		{
+			c:         config{numTimes: 5},
+			visitors:  1,
+			greeted:   5,
+			greetings: 5,
+			success:   true,
+		},
+		{
+			c:         config{numTimes: 2, session: true},
+			visitors:  3,
+			greeted:   6,
+			greetings: 6,
+			success:   true,
+		},
+		{
+			c:         config{numTimes: 5},
+			visitors:  0,
+			err:       errors.New("you didn't enter your name"),
+			greetings: 0,
+			success:   false,
+			errMsg:    "you didn't enter your name",
+		},
+		{
+			c:         config{numTimes: 5000},
+			visitors:  1,
+			greeted:   1024,
+			err:       errInterrupted,
+			greetings: 1024,
+			success:   false,
+			errMsg:    errInterrupted.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		path := filepath.Join(t.TempDir(), "report.json")
+		r := newRunReport()
+		r.finish(tc.c, tc.visitors, tc.greeted, tc.err)
+		if err := writeReport(path, r); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		var got runReport
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("expected valid json, got: %v\n", err)
+		}
+		if got.Config.NumTimes != tc.c.numTimes {
+			t.Errorf("expected num_times to be: %v, got: %v\n", tc.c.numTimes, got.Config.NumTimes)
+		}
+		if got.Greetings != tc.greetings {
+			t.Errorf("expected greetings to be: %v, got: %v\n", tc.greetings, got.Greetings)
+		}
+		if got.Success != tc.success {
+			t.Errorf("expected success to be: %v, got: %v\n", tc.success, got.Success)
+		}
+		if got.Error != tc.errMsg {
+			t.Errorf("expected error to be: %v, got: %v\n", tc.errMsg, got.Error)
+		}
+	}
+}
+
+func TestWriteReportUnwritablePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "report.json")
+	err := writeReport(path, newRunReport())
+	if err == nil {
+		t.Fatal("expected an error writing to a missing directory, got nil")
+	}
+}
+
+func TestReportOptions(t *testing.T) {
+	c, err := parseArgs([]string{"--name", "Benny", "--output", "json", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRunReport()
+	r.finish(c, 1, 3, nil)
+
+	expected := map[string]reportOption{
+		"name":   {Value: "Benny", Origin: originFlag},
+		"output": {Value: "json", Origin: originFlag},
+		"times":  {Value: "3", Origin: originFlag},
+		"lang":   {Value: "", Origin: originDefault},
+	}
+	for name, o := range expected {
+		if got := r.Config.Options[name]; got != o {
+			t.Errorf("%s: expected %+v, got: %+v\n", name, o, got)
+		}
+	}
+	if _, ok := r.Config.Options["help"]; ok {
+		t.Errorf("expected no --help in the report, got: %+v\n", r.Config.Options)
+	}
+}
+
+// TestReportGreetings checks the count comes from the greetings written,
+// not the number asked for.
+func TestReportGreetings(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	greeted := 0
+	c := withDefaults(config{name: "Benny", numTimes: 5000, template: "{{.Index}}", greeted: &greeted})
+	out := new(bytes.Buffer)
+	if _, err := runCommand(ctx, strings.NewReader(""), out, c); err == nil {
+		t.Fatal("expected the cancelled run to fail, got nil")
+	}
+	if lines := strings.Count(out.String(), "\n"); greeted != lines || greeted >= 5000 {
+		t.Errorf("expected %v greetings counted, got: %v\n", lines, greeted)
+	}
+}
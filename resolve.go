@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix starts the name of the environment variable of every option,
+// NAME_CLI_TIMES for --times and so on.
+const envPrefix = "NAME_CLI_"
+
+// envAliases are further variables for some options, checked after the
+// one named after the flag.
+var envAliases = map[string]string{
+	"template": "NAME_CLI_GREETING",
+}
+
+// envName is the environment variable for a flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// resolveConfig fills in the options that weren't given on the command
+// line, first from the environment and then from the config file, so the
+// precedence is flags > environment > config file > defaults. It runs on
+// the FlagSet c was parsed with.
+func resolveConfig(fs *flag.FlagSet, c *config) error {
+	if err := applyEnv(fs, c); err != nil {
+		return err
+	}
+	return applyConfigFile(fs, c)
+}
+
+// resolveEnv is resolveConfig for the commands without a config file:
+// options given on the command line win over the environment.
+func resolveEnv(fs *flag.FlagSet, c *config) error {
+	fs.Visit(func(f *flag.Flag) {
+		c.setOrigin(f.Name, originFlag)
+	})
+	return applyEnv(fs, c)
+}
+
+// applyEnv sets the options that have a NAME_CLI_ variable. Setting
+// NAME_CLI_CONFIG picks the config file.
+func applyEnv(fs *flag.FlagSet, c *config) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, alias := flagAliases[f.Name]; alias || exitFlags[f.Name] || err != nil {
+			return
+		}
+		if c.origin[f.Name] != "" {
+			return
+		}
+		name := envName(f.Name)
+		value, ok := os.LookupEnv(name)
+		if alias, hasAlias := envAliases[f.Name]; !ok && hasAlias {
+			name = alias
+			value, ok = os.LookupEnv(name)
+		}
+		if !ok {
+			return
+		}
+		if serr := fs.Set(f.Name, value); serr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %v", value, name, serr)
+			return
+		}
+		c.setOrigin(f.Name, "$"+name)
+	})
+	return err
+}
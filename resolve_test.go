@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	for flagName, env := range map[string]string{"times": "NAME_CLI_TIMES", "match-threshold": "NAME_CLI_MATCH_THRESHOLD"} {
+		if got := envName(flagName); got != env {
+			t.Errorf("%s: expected %s, got: %s\n", flagName, env, got)
+		}
+	}
+}
+
+func TestResolveConfigPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	writeConfigFile(t, home, "name-cli/config.toml", "times = 3\nname = \"Benny\"\nfilter = \"mask\"\n")
+	other := writeConfigFile(t, t.TempDir(), "kiosk.yaml", "session: true\n")
+
+	tests := []struct {
+		env    map[string]string
+		args   []string
+		times  int
+		name   string
+		origin map[string]string
+	}{
+		{
+			env:    map[string]string{"NAME_CLI_TIMES": "4"},
+			args:   []string{},
+			times:  4,
+			name:   "Benny",
+			origin: map[string]string{"times": "$NAME_CLI_TIMES", "name": originConfig, "filter": originConfig},
+		},
+		{
+			env:    map[string]string{"NAME_CLI_TIMES": "4", "NAME_CLI_NAME": "Ada"},
+			args:   []string{"5"},
+			times:  5,
+			name:   "Ada",
+			origin: map[string]string{"times": originFlag, "name": "$NAME_CLI_NAME", "filter": originConfig},
+		},
+		{
+			env:    map[string]string{"NAME_CLI_CONFIG": other},
+			args:   []string{"1"},
+			times:  1,
+			origin: map[string]string{"times": originFlag, "config": "$NAME_CLI_CONFIG", "session": originConfig},
+		},
+	}
+
+	for _, tc := range tests {
+		// each case in a subtest, so its variables are unset again after it
+		t.Run("", func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			c, err := parseArgs(tc.args)
+			if err != nil {
+				t.Fatalf("%v: expected nil error, got: %v\n", tc.env, err)
+			}
+			if c.numTimes != tc.times || c.name != tc.name {
+				t.Errorf("%v: expected %d times and name %q, got: %d and %q\n", tc.env, tc.times, tc.name, c.numTimes, c.name)
+			}
+			if !reflect.DeepEqual(c.origin, tc.origin) {
+				t.Errorf("%v: expected origins %v, got: %v\n", tc.env, tc.origin, c.origin)
+			}
+		})
+	}
+}
+
+func TestResolveEnvCommands(t *testing.T) {
+	if !hasServe {
+		t.Skip("built with -tags noserve")
+	}
+	t.Setenv("NAME_CLI_ADDR", ":9000")
+	c, err := parseArgs([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.listenAddr != ":9000" {
+		t.Errorf("expected the address from the environment, got: %q\n", c.listenAddr)
+	}
+	c, _ = parseArgs([]string{"serve", "--addr", ":9001"})
+	if c.listenAddr != ":9001" {
+		t.Errorf("expected --addr to win over the environment, got: %q\n", c.listenAddr)
+	}
+}
+
+func TestResolveEnvErrors(t *testing.T) {
+	t.Run("invalid value", func(t *testing.T) {
+		t.Setenv("NAME_CLI_TIMES", "lots")
+		_, err := parseArgs([]string{})
+		expected := errors.New(`invalid value "lots" for NAME_CLI_TIMES: parse error`)
+		if err == nil || err.Error() != expected.Error() {
+			t.Errorf("expected error to be: %v, got: %v\n", expected, err)
+		}
+	})
+
+	t.Run("no variable", func(t *testing.T) {
+		// exit flags and aliases have no variable
+		t.Setenv("NAME_CLI_HELP", "true")
+		t.Setenv("NAME_CLI_N", "3")
+		if c, err := parseArgs([]string{"1"}); err != nil || c.printUsage || c.numTimes != 1 {
+			t.Errorf("expected NAME_CLI_HELP and NAME_CLI_N to be ignored, got: %+v, %v\n", c, err)
+		}
+	})
+}
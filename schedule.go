@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var errScheduleCancelled = errors.New("scheduled greeting cancelled")
+
+// parseClock parses a time of day given to --at. Only the hour, minute and
+// second of the result are meaningful.
+func parseClock(s string) (time.Time, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+}
+
+// nextOccurrence returns the first time at or after now which has the
+// hour, minute and second of clock, so --at 09:00 given in the evening
+// waits until tomorrow morning.
+func nextOccurrence(now, clock time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+	if next.Before(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func scheduledTime(now time.Time, c config) time.Time {
+	if !c.at.IsZero() {
+		return nextOccurrence(now, c.at)
+	}
+	return now.Add(c.in)
+}
+
+// waitForSchedule blocks until the time requested with --at or --in,
+// showing a countdown when w is a terminal. An interrupt while waiting
+// cancels the greeting.
+func waitForSchedule(ctx context.Context, w io.Writer, c config) error {
+	if c.at.IsZero() && c.in == 0 {
+		return nil
+	}
+	return waitUntil(ctx, w, scheduledTime(time.Now(), c), isTerminal(w))
+}
+
+func waitUntil(ctx context.Context, w io.Writer, until time.Time, countdown bool) error {
+	timer := time.NewTimer(time.Until(until))
+	defer timer.Stop()
+
+	var tick <-chan time.Time
+	if countdown {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		tick = ticker.C
+		printCountdown(w, time.Until(until))
+		// the countdown line is overwritten in place, clear it when done
+		defer fmt.Fprint(w, "\r\033[K")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return errScheduleCancelled
+		case <-timer.C:
+			return nil
+		case <-tick:
+			printCountdown(w, time.Until(until))
+		}
+	}
+}
+
+func printCountdown(w io.Writer, left time.Duration) {
+	if left < 0 {
+		left = 0
+	}
+	fmt.Fprintf(w, "\r\033[KGreeting in %s", left.Round(time.Second))
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		input   string
+		hour    int
+		minute  int
+		second  int
+		wantErr bool
+	}{
+		{input: "17:00", hour: 17},
+		{input: "09:30:15", hour: 9, minute: 30, second: 15},
+		{input: "25:00", wantErr: true},
+		{input: "5pm", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := parseClock(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("expected an error parsing %q, got nil\n", tc.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if got.Hour() != tc.hour || got.Minute() != tc.minute || got.Second() != tc.second {
+			t.Errorf("expected %q to be parsed as %02d:%02d:%02d, got: %v\n", tc.input, tc.hour, tc.minute, tc.second, got)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	clock, _ := parseClock("17:00")
+	tests := []struct {
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			now:      time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 10, 14, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			now:      time.Date(2026, 10, 14, 17, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 10, 14, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			now:      time.Date(2026, 10, 14, 18, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 10, 15, 17, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		if got := nextOccurrence(tc.now, clock); !got.Equal(tc.expected) {
+			t.Errorf("expected next occurrence after %v to be: %v, got: %v\n", tc.now, tc.expected, got)
+		}
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	byteBuf := new(bytes.Buffer)
+	err := waitUntil(context.Background(), byteBuf, time.Now().Add(10*time.Millisecond), true)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if !strings.HasPrefix(byteBuf.String(), "\r\033[KGreeting in ") {
+		t.Errorf("expected a countdown to be printed, got: %q\n", byteBuf.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	byteBuf.Reset()
+	err = waitUntil(ctx, byteBuf, time.Now().Add(time.Hour), false)
+	if err != errScheduleCancelled {
+		t.Errorf("expected error: %v, got: %v\n", errScheduleCancelled, err)
+	}
+	if byteBuf.Len() != 0 {
+		t.Errorf("expected no countdown without a terminal, got: %q\n", byteBuf.String())
+	}
+}
+
+func TestRunCmdScheduled(t *testing.T) {
+	byteBuf := new(bytes.Buffer)
+	start := time.Now()
+	err := runCmd(context.Background(), strings.NewReader("Benny"), byteBuf, config{numTimes: 1, in: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Errorf("expected the greeting to wait for --in")
+	}
+	expected := "Your name please? Press the return key when done.\nNice to meet you Benny\n"
+	if got := byteBuf.String(); got != expected {
+		t.Errorf("expected stdout message to be: %v, got: %v\n", expected, got)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// schemaVersion is written to every JSON document the tool produces. Bump
+// it, and the schemas under schemas/, whenever a field is removed or
+// changes meaning.
+const schemaVersion = 1
+
+//go:embed schemas/*.schema.json
+var schemaFS embed.FS
+
+func schemaNames() []string {
+	entries, _ := schemaFS.ReadDir("schemas")
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".schema.json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printSchema(w io.Writer, name string) error {
+	data, err := schemaFS.ReadFile("schemas/" + name + ".schema.json")
+	if err != nil {
+		return fmt.Errorf("unknown schema %q, expected one of: %s", name, strings.Join(schemaNames(), ", "))
+	}
+	_, err = w.Write(data)
+	return err
+}
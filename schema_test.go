@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintSchema(t *testing.T) {
+	for _, name := range []string{"audit", "greeting", "report", "stats"} {
+		byteBuf := new(bytes.Buffer)
+		if err := printSchema(byteBuf, name); err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(byteBuf.Bytes(), &doc); err != nil {
+			t.Errorf("expected the %v schema to be valid json, got: %v\n", name, err)
+		}
+	}
+
+	err := printSchema(new(bytes.Buffer), "history")
+	expected := `unknown schema "history", expected one of: audit, greeting, report, stats`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error: %v, got: %v\n", expected, err)
+	}
+}
+
+// TestSchemasMatchStructs makes sure every field we write is described by
+// the published schema, so the two can't drift apart.
+func TestSchemasMatchStructs(t *testing.T) {
+	tests := []struct {
+		schema string
+		value  interface{}
+	}{
+		{schema: "audit", value: auditEntry{}},
+		{schema: "greeting", value: greetingRecord{}},
+		{schema: "report", value: runReport{}},
+		{schema: "stats", value: sessionStatsFile{}},
+	}
+
+	for _, tc := range tests {
+		byteBuf := new(bytes.Buffer)
+		if err := printSchema(byteBuf, tc.schema); err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal(byteBuf.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		typ := reflect.TypeOf(tc.value)
+		for i := 0; i < typ.NumField(); i++ {
+			field := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+			if _, ok := doc.Properties[field]; !ok {
+				t.Errorf("expected the %v schema to describe field %q\n", tc.schema, field)
+			}
+		}
+	}
+}
+
+func TestJSONOutputsCarrySchemaVersion(t *testing.T) {
+	if v := newRunReport().SchemaVersion; v != schemaVersion {
+		t.Errorf("expected report schema_version to be: %v, got: %v\n", schemaVersion, v)
+	}
+	if v := newSessionStats(time.Now()).summary(time.Now()).SchemaVersion; v != schemaVersion {
+		t.Errorf("expected stats schema_version to be: %v, got: %v\n", schemaVersion, v)
+	}
+}
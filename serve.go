@@ -0,0 +1,248 @@
+//go:build !noserve
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hasServe is false in builds with -tags noserve, see noserve.go.
+const hasServe = true
+
+const (
+	defaultListenAddr = "localhost:8080"
+	defaultMaxTimes   = 10000
+	// shutdownTimeout is how long in-flight requests get to finish once
+	// the server has been asked to stop.
+	shutdownTimeout = 5 * time.Second
+)
+
+func newServeFlagSet(c *config) *flag.FlagSet {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&c.printUsage, "help", c.printUsage, "show this help and exit")
+	fs.BoolVar(&c.printUsage, "h", c.printUsage, "show this help and exit")
+	fs.StringVar(&c.listenAddr, "addr", c.listenAddr, "`host:port` to listen on")
+	fs.IntVar(&c.maxTimes, "max-times", c.maxTimes, "largest `count` a single request may ask for, 0 for no limit")
+	fs.DurationVar(&c.idleTimeout, "idle-timeout", c.idleTimeout, "shut down after this `duration` without requests, 0 to keep running")
+	fs.StringVar(&c.handoffSocket, "handoff-socket", c.handoffSocket, "unix socket `path` a new server can take the listener over from")
+	fs.BoolVar(&c.upgrade, "upgrade", c.upgrade, "take the listener over from the server on --handoff-socket instead of listening on --addr")
+	return fs
+}
+
+func serveDefaults() config {
+	return config{command: "serve", listenAddr: defaultListenAddr, maxTimes: defaultMaxTimes}
+}
+
+func parseServeArgs(args []string) (config, error) {
+	c := serveDefaults()
+	fs := newServeFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return config{printUsage: true, command: "serve"}, nil
+	}
+	if err := resolveEnv(fs, &c); err != nil {
+		return config{}, err
+	}
+	if fs.NArg() != 0 {
+		return config{}, ErrInvalidArgCount
+	}
+	return c, nil
+}
+
+func validateServeArgs(c config) error {
+	if c.listenAddr == "" {
+		return errors.New("--addr must not be empty")
+	}
+	if c.maxTimes < 0 {
+		return errors.New("--max-times must not be negative")
+	}
+	if c.idleTimeout < 0 {
+		return errors.New("--idle-timeout must not be negative")
+	}
+	if c.upgrade && c.handoffSocket == "" {
+		return errors.New("--upgrade needs --handoff-socket")
+	}
+	return nil
+}
+
+// greetHandler answers GET /greet?name=X&times=N with the same output the
+// command line prints for --name X N.
+func greetHandler(sc config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		c, err := requestConfig(sc, r)
+		if err == nil {
+			err = validateArgs(c)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if r.Method == http.MethodHead {
+			return
+		}
+		if err := runCmd(r.Context(), strings.NewReader(""), w, c); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func requestConfig(sc config, r *http.Request) (config, error) {
+	q := r.URL.Query()
+	// the history is the user's own greetings, not every request to the
+	// server or a loadtest
+	c := config{name: q.Get("name"), noHistory: true}
+	if c.name == "" {
+		return c, ErrEmptyName
+	}
+	// the name came from the request, validateArgs should treat it like --name
+	c.setOrigin("name", "request")
+
+	times := q.Get("times")
+	if times == "" {
+		times = "1"
+	}
+	numTimes, err := strconv.Atoi(times)
+	if err != nil {
+		return c, &ParseError{Token: times, Err: fmt.Errorf("invalid times %q", times)}
+	}
+	if sc.maxTimes > 0 && numTimes > sc.maxTimes {
+		return c, fmt.Errorf("times must not be more than %d", sc.maxTimes)
+	}
+	c.numTimes = numTimes
+	return c, nil
+}
+
+func newServeMux(c config) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/greet", greetHandler(c))
+	return mux
+}
+
+// idleTracker closes done once no request has been in flight for
+// timeout, for --idle-timeout.
+type idleTracker struct {
+	timeout  time.Duration
+	done     chan struct{}
+	once     sync.Once
+	mu       sync.Mutex
+	inFlight int
+	timer    *time.Timer
+}
+
+func newIdleTracker(timeout time.Duration) *idleTracker {
+	t := &idleTracker{timeout: timeout, done: make(chan struct{})}
+	t.timer = time.AfterFunc(timeout, func() {
+		t.once.Do(func() { close(t.done) })
+	})
+	return t
+}
+
+// track wraps h so the timer is stopped while requests are in flight and
+// starts over once the last one is done.
+func (t *idleTracker) track(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.mu.Lock()
+		t.inFlight++
+		t.timer.Stop()
+		t.mu.Unlock()
+		defer func() {
+			t.mu.Lock()
+			t.inFlight--
+			if t.inFlight == 0 {
+				t.timer.Reset(t.timeout)
+			}
+			t.mu.Unlock()
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// serve answers requests on ln until ctx is cancelled, it has been idle
+// for c.idleTimeout or a new server took ln over through
+// c.handoffSocket, then gives in-flight requests shutdownTimeout to
+// finish.
+func serve(ctx context.Context, ln net.Listener, w io.Writer, c config) error {
+	var handler http.Handler = newServeMux(c)
+	var idle <-chan struct{}
+	if c.idleTimeout > 0 {
+		t := newIdleTracker(c.idleTimeout)
+		defer t.timer.Stop()
+		handler, idle = t.track(handler), t.done
+	}
+	var handedOff <-chan struct{}
+	if c.handoffSocket != "" {
+		h, err := listenHandoff(c.handoffSocket, ln)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		defer h.Close()
+		handedOff = h.done
+	}
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+	fmt.Fprintf(w, "Listening on http://%s\n", ln.Addr())
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	case <-idle:
+		fmt.Fprintf(w, "No requests for %s\n", c.idleTimeout)
+	case <-handedOff:
+		fmt.Fprintln(w, "Handed the listener over to the new server")
+	}
+
+	fmt.Fprintln(w, "Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func runServe(ctx context.Context, w io.Writer, c config) error {
+	var ln net.Listener
+	var err error
+	if c.upgrade {
+		ln, err = takeListener(c.handoffSocket)
+	} else {
+		ln, err = net.Listen("tcp", c.listenAddr)
+	}
+	if err != nil {
+		return err
+	}
+	return serve(ctx, ln, w, c)
+}
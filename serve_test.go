@@ -0,0 +1,270 @@
+//go:build !noserve
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseServeArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+		c    config
+	}{
+		{
+			args: []string{},
+			c:    config{command: "serve", listenAddr: defaultListenAddr, maxTimes: defaultMaxTimes},
+		},
+		{
+			args: []string{"--addr", ":9000", "--max-times", "5"},
+			c:    config{command: "serve", listenAddr: ":9000", maxTimes: 5},
+		},
+		{
+			args: []string{"-h"},
+			c:    config{printUsage: true, command: "serve"},
+		},
+		{
+			args: []string{"5"},
+			err:  ErrInvalidArgCount,
+		},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"serve"}, tc.args...))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("expected error to be: %v, got: %v\n", tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		if c.command != tc.c.command || c.listenAddr != tc.c.listenAddr || c.maxTimes != tc.c.maxTimes || c.printUsage != tc.c.printUsage {
+			t.Errorf("expected config to be: %+v, got: %+v\n", tc.c, c)
+		}
+	}
+}
+
+func TestServeUsage(t *testing.T) {
+	var b bytes.Buffer
+	printCommandUsage(&b, "name-cli", "serve", catalogs[defaultLang])
+	if !strings.Contains(b.String(), "(default "+defaultListenAddr+")") {
+		t.Errorf("expected the serve defaults in its usage, got: %q\n", b.String())
+	}
+
+	script := bashCompletion("name-cli")
+	want := `serve) words="--addr --handoff-socket --help --idle-timeout --max-times --upgrade -h" ;;`
+	if !strings.Contains(script, want) {
+		t.Errorf("expected completion to contain %q, got:\n%s\n", want, script)
+	}
+}
+
+func TestGreetHandler(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		status int
+		body   string
+	}{
+		{
+			method: http.MethodGet,
+			target: "/greet?name=Benny&times=3",
+			status: http.StatusOK,
+			body:   strings.Repeat("Nice to meet you Benny\n", 3),
+		},
+		{
+			method: http.MethodGet,
+			target: "/greet?name=Benny",
+			status: http.StatusOK,
+			body:   "Nice to meet you Benny\n",
+		},
+		{
+			method: http.MethodGet,
+			target: "/greet?times=3",
+			status: http.StatusBadRequest,
+			body:   "you didn't enter your name\n",
+		},
+		{
+			method: http.MethodGet,
+			target: "/greet?name=Benny&times=0",
+			status: http.StatusBadRequest,
+			body:   "must specify a number greater than 0\n",
+		},
+		{
+			method: http.MethodGet,
+			target: "/greet?name=Benny&times=abc",
+			status: http.StatusBadRequest,
+			body:   "invalid times \"abc\"\n",
+		},
+		{
+			method: http.MethodGet,
+			target: "/greet?name=Benny&times=11",
+			status: http.StatusBadRequest,
+			body:   "times must not be more than 10\n",
+		},
+		{
+			method: http.MethodPost,
+			target: "/greet?name=Benny",
+			status: http.StatusMethodNotAllowed,
+			body:   "method not allowed\n",
+		},
+	}
+
+	h := greetHandler(config{command: "serve", maxTimes: 10})
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: expected status %v, got: %v\n", tc.method, tc.target, tc.status, rec.Code)
+		}
+		if got := rec.Body.String(); got != tc.body {
+			t.Errorf("%s %s: expected body to be: %q, got: %q\n", tc.method, tc.target, tc.body, got)
+		}
+	}
+}
+
+func TestGreetHandlerKeepsNoHistory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	rec := httptest.NewRecorder()
+	greetHandler(config{command: "serve"}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/greet?name=Benny&times=3", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %v, got: %v\n", http.StatusOK, rec.Code)
+	}
+	entries, err := openHistory().entries()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected the request to be left out of the history, got: %+v, %v\n", entries, err)
+	}
+}
+
+// TestServeConcurrentRequests checks requests don't see each other's
+// greetings, run it with -race.
+func TestServeConcurrentRequests(t *testing.T) {
+	srv := httptest.NewServer(newServeMux(serveDefaults()))
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name, times := fmt.Sprintf("Visitor%d", i), 1+i%4
+			resp, err := srv.Client().Get(fmt.Sprintf("%s/greet?name=%s&times=%d", srv.URL, name, times))
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if expected := strings.Repeat("Nice to meet you "+name+"\n", times); string(body) != expected {
+				errs <- fmt.Sprintf("%s: expected %q, got: %q", name, expected, body)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestServeShutsDownOnCancel(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := new(bytes.Buffer)
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, ln, out, config{command: "serve"})
+	}()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/greet?name=Benny&times=2")
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != strings.Repeat("Nice to meet you Benny\n", 2) {
+		t.Errorf("expected greetings in the response, got: %q\n", body)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got: %v\n", err)
+		}
+	case <-time.After(shutdownTimeout + time.Second):
+		t.Fatal("server did not shut down")
+	}
+	if !strings.HasPrefix(out.String(), "Listening on http://") || !strings.HasSuffix(out.String(), "Shutting down\n") {
+		t.Errorf("expected startup and shutdown messages, got: %q\n", out.String())
+	}
+}
+
+func TestServeIdleTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(context.Background(), ln, out, config{command: "serve", idleTimeout: 50 * time.Millisecond})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got: %v\n", err)
+		}
+	case <-time.After(shutdownTimeout):
+		t.Fatal("server did not shut down when idle")
+	}
+	if !strings.HasSuffix(out.String(), "No requests for 50ms\nShutting down\n") {
+		t.Errorf("expected the idle shutdown message, got: %q\n", out.String())
+	}
+}
+
+func TestIdleTrackerWaitsForRequests(t *testing.T) {
+	tracker := newIdleTracker(100 * time.Millisecond)
+	started, release := make(chan struct{}), make(chan struct{})
+	h := tracker.track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	served := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/greet?name=Benny", nil))
+		close(served)
+	}()
+	// outlast the timeout with the request still in flight
+	<-started
+	select {
+	case <-tracker.done:
+		t.Fatal("expected the tracker to wait for the request in flight")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	close(release)
+	<-served
+	select {
+	case <-tracker.done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the tracker to finish once the request was done")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultServiceLabel names the launchd job unless --label says otherwise.
+const defaultServiceLabel = "com.github.jordanengstrom.name-cli"
+
+func newServiceFlagSet(c *config) *flag.FlagSet {
+	fs := newHelpFlagSet(c)
+	fs.StringVar(&c.serviceLabel, "label", c.serviceLabel, "`label` of the launchd job")
+	fs.StringVar(&c.serviceFile, "out", c.serviceFile, "write the plist to `file` instead of stdout")
+	fs.StringVar(&c.serviceLog, "log", c.serviceLog, "send the output of the job to `file`")
+	fs.Var(&funcValue{
+		get: func() string {
+			if c.at.IsZero() {
+				return ""
+			}
+			return c.at.Format("15:04")
+		},
+		set: func(v string) (err error) {
+			c.at, err = parseClock(v)
+			return err
+		},
+	}, "at", "run every day at the given time (`HH:MM`) instead of keeping the job running")
+	fs.BoolVar(&c.serviceLoad, "load", c.serviceLoad, "load the job with launchctl, writing it to ~/Library/LaunchAgents unless --out is given")
+	return fs
+}
+
+func serviceDefaults() config {
+	return config{command: "service", serviceLabel: defaultServiceLabel}
+}
+
+// parseServiceArgs parses "service launchd [options] [-- args]", the args
+// being what the job runs the tool with.
+func parseServiceArgs(args []string) (config, error) {
+	c := serviceDefaults()
+	fs := newServiceFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	rest := fs.Args()
+	if len(rest) > 0 && rest[0] != "--" {
+		c.serviceAction = rest[0]
+		if err := fs.Parse(rest[1:]); err != nil {
+			return config{}, err
+		}
+		rest = fs.Args()
+	}
+	if c.printUsage {
+		return config{printUsage: true, command: "service"}, nil
+	}
+
+	switch c.serviceAction {
+	case "launchd":
+	case "":
+		return config{}, errors.New("expected launchd")
+	default:
+		return config{}, fmt.Errorf("unknown service manager %q, expected launchd", c.serviceAction)
+	}
+	if len(rest) > 0 && rest[0] == "--" {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		c.serviceArgs = rest
+	}
+	return c, nil
+}
+
+func validateServiceArgs(c config) error {
+	if c.serviceLabel == "" || strings.ContainsAny(c.serviceLabel, "/ ") {
+		return fmt.Errorf("--label must be a reverse DNS name like %s, got %q", defaultServiceLabel, c.serviceLabel)
+	}
+	args := jobArgs(c)
+	if args[0] == "service" {
+		return errors.New("the job cannot run the service command itself")
+	}
+	// better now than in the job's log
+	jc, err := parseArgs(args)
+	if err == nil {
+		err = validateArgs(jc)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid job arguments %q: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// jobArgs is what the job runs the tool with: serve by default, or a
+// single greeting with --at, which comes from the OS user or
+// --default-name as nobody is there to type a name.
+func jobArgs(c config) []string {
+	if len(c.serviceArgs) > 0 {
+		return c.serviceArgs
+	}
+	if !c.at.IsZero() {
+		return []string{"1"}
+	}
+	return []string{"serve"}
+}
+
+// launchdPlist is the launchd job for c, running the tool at exe. Without
+// --at it is started at login and restarted if it exits, with --at it
+// runs at that time every day.
+func launchdPlist(c config, exe string) string {
+	b := new(strings.Builder)
+	str := func(indent, s string) {
+		b.WriteString(indent + "<string>")
+		xml.EscapeText(b, []byte(s))
+		b.WriteString("</string>\n")
+	}
+	key := func(k string) {
+		fmt.Fprintf(b, "\t<key>%s</key>\n", k)
+	}
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	key("Label")
+	str("\t", c.serviceLabel)
+	key("ProgramArguments")
+	b.WriteString("\t<array>\n")
+	for _, arg := range append([]string{exe}, jobArgs(c)...) {
+		str("\t\t", arg)
+	}
+	b.WriteString("\t</array>\n")
+
+	if c.at.IsZero() {
+		key("RunAtLoad")
+		b.WriteString("\t<true/>\n")
+		key("KeepAlive")
+		b.WriteString("\t<true/>\n")
+	} else {
+		key("StartCalendarInterval")
+		fmt.Fprintf(b, "\t<dict>\n\t\t<key>Hour</key>\n\t\t<integer>%d</integer>\n\t\t<key>Minute</key>\n\t\t<integer>%d</integer>\n\t</dict>\n", c.at.Hour(), c.at.Minute())
+	}
+
+	if c.serviceLog != "" {
+		key("StandardOutPath")
+		str("\t", c.serviceLog)
+		key("StandardErrorPath")
+		str("\t", c.serviceLog)
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// serviceFile is where the plist goes: --out, or the user's LaunchAgents
+// with --load, "" for stdout.
+func serviceFile(c config) (string, error) {
+	if c.serviceFile != "" || !c.serviceLoad {
+		return c.serviceFile, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("no home directory for the plist, give one with --out")
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", c.serviceLabel+".plist"), nil
+}
+
+// launchctlTimeout is how long launchctl gets to load the job.
+const launchctlTimeout = 30 * time.Second
+
+func runService(w io.Writer, c config) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find the path of name-cli: %w", err)
+	}
+	plist := launchdPlist(c, exe)
+
+	path, err := serviceFile(c)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		_, err := io.WriteString(w, plist)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not write plist: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(plist), 0644); err != nil {
+		return fmt.Errorf("could not write plist: %w", err)
+	}
+	fmt.Fprintf(w, "Wrote %s\n", path)
+	if !c.serviceLoad {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), launchctlTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "launchctl", "load", "-w", path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not load %s: %v: %s", path, err, strings.TrimSpace(string(out)))
+	}
+	fmt.Fprintf(w, "Loaded %s\n", c.serviceLabel)
+	return nil
+}
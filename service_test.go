@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseServiceArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  error
+		c    config
+	}{
+		{
+			args: []string{"launchd"},
+			c:    config{command: "service", serviceAction: "launchd", serviceLabel: defaultServiceLabel},
+		},
+		{
+			args: []string{"launchd", "--at", "9:30", "--out", "greet.plist", "--", "--name", "Benny", "1"},
+			c: config{command: "service", serviceAction: "launchd", serviceLabel: defaultServiceLabel, serviceFile: "greet.plist",
+				at: time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC), serviceArgs: []string{"--name", "Benny", "1"}},
+		},
+		{
+			args: []string{"--label", "org.example.greet", "--load", "launchd", "serve"},
+			c:    config{command: "service", serviceAction: "launchd", serviceLabel: "org.example.greet", serviceLoad: true, serviceArgs: []string{"serve"}},
+		},
+		{
+			args: []string{"launchd", "-h"},
+			c:    config{command: "service", printUsage: true},
+		},
+		{
+			args: []string{},
+			err:  errors.New("expected launchd"),
+		},
+		{
+			args: []string{"systemd"},
+			err:  errors.New(`unknown service manager "systemd", expected launchd`),
+		},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"service"}, tc.args...))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Fatalf("%v: expected error to be: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Fatalf("%v: expected nil error, got: %v\n", tc.args, err)
+		}
+		if !reflect.DeepEqual(c, tc.c) {
+			t.Errorf("%v: expected config to be: %+v, got: %+v\n", tc.args, tc.c, c)
+		}
+	}
+}
+
+func TestValidateServiceArgs(t *testing.T) {
+	tests := []struct {
+		c   config
+		err string
+	}{
+		{c: config{command: "service", serviceLabel: defaultServiceLabel, serviceArgs: []string{"--name", "Benny", "1"}}},
+		{c: config{command: "service", serviceLabel: "my job"}, err: `--label must be a reverse DNS name like com.github.jordanengstrom.name-cli, got "my job"`},
+		{c: config{command: "service", serviceLabel: defaultServiceLabel, serviceArgs: []string{"service", "launchd"}}, err: "the job cannot run the service command itself"},
+		{c: config{command: "service", serviceLabel: defaultServiceLabel, serviceArgs: []string{"0"}}, err: `invalid job arguments "0": must specify a number greater than 0`},
+	}
+	for _, tc := range tests {
+		err := validateArgs(tc.c)
+		if tc.err == "" && err != nil {
+			t.Errorf("expected nil error, got: %v\n", err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("expected error: %v, got: %v\n", tc.err, err)
+		}
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	c := serviceDefaults()
+	got := launchdPlist(c, "/usr/local/bin/name-cli")
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.github.jordanengstrom.name-cli</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/usr/local/bin/name-cli</string>
+		<string>serve</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+</dict>
+</plist>
+`
+	if got != expected {
+		t.Errorf("expected plist to be:\n%s\ngot:\n%s\n", expected, got)
+	}
+
+	c.at = time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC)
+	c.serviceLog = "/tmp/name-cli.log"
+	c.serviceArgs = []string{"--name", "Benny & Ada", "1"}
+	got = launchdPlist(c, "/usr/local/bin/name-cli")
+	for _, want := range []string{
+		"\t\t<string>Benny &amp; Ada</string>\n",
+		"\t<key>StartCalendarInterval</key>\n\t<dict>\n\t\t<key>Hour</key>\n\t\t<integer>9</integer>\n\t\t<key>Minute</key>\n\t\t<integer>30</integer>\n\t</dict>\n",
+		"\t<key>StandardOutPath</key>\n\t<string>/tmp/name-cli.log</string>\n",
+		"\t<key>StandardErrorPath</key>\n\t<string>/tmp/name-cli.log</string>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected plist to contain %q, got:\n%s\n", want, got)
+		}
+	}
+	if strings.Contains(got, "KeepAlive") {
+		t.Errorf("expected a scheduled job not to be kept alive, got:\n%s\n", got)
+	}
+	// with --at and no arguments the job greets once
+	c.serviceArgs = nil
+	if args := jobArgs(c); !reflect.DeepEqual(args, []string{"1"}) {
+		t.Errorf("expected the job to greet once, got: %v\n", args)
+	}
+}
+
+func TestRunService(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agents", "greet.plist")
+	c := serviceDefaults()
+	c.serviceFile = path
+	out := new(bytes.Buffer)
+	if err := runService(out, c); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if out.String() != "Wrote "+path+"\n" {
+		t.Errorf("expected the path to be reported, got: %q\n", out.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "<key>KeepAlive</key>") {
+		t.Errorf("expected the plist to be written, got: %v: %s\n", err, data)
+	}
+
+	// with --load and no --out it goes where launchd looks for it
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	c = serviceDefaults()
+	c.serviceLoad = true
+	if got, _ := serviceFile(c); got != filepath.Join(home, "Library", "LaunchAgents", defaultServiceLabel+".plist") {
+		t.Errorf("expected the plist in LaunchAgents, got: %v\n", got)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// sessionStats keeps visitor counts for a --session run, bucketed by the
+// hour the visitor was greeted in.
+type sessionStats struct {
+	startedAt time.Time
+	visitors  int
+	names     map[string]bool
+	hours     map[time.Time]*hourStats
+}
+
+type hourStats struct {
+	visitors int
+	names    map[string]bool
+}
+
+type sessionStatsFile struct {
+	SchemaVersion int             `json:"schema_version"`
+	StartedAt     time.Time       `json:"started_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Visitors      int             `json:"visitors"`
+	UniqueNames   int             `json:"unique_names"`
+	Hours         []hourStatsFile `json:"hours"`
+}
+
+type hourStatsFile struct {
+	Hour        time.Time `json:"hour"`
+	Visitors    int       `json:"visitors"`
+	UniqueNames int       `json:"unique_names"`
+}
+
+func newSessionStats(now time.Time) *sessionStats {
+	return &sessionStats{
+		startedAt: now,
+		names:     map[string]bool{},
+		hours:     map[time.Time]*hourStats{},
+	}
+}
+
+func (s *sessionStats) record(name string, at time.Time) {
+	key := normalizeName(name)
+	hour := time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), 0, 0, 0, at.Location())
+	h, ok := s.hours[hour]
+	if !ok {
+		h = &hourStats{names: map[string]bool{}}
+		s.hours[hour] = h
+	}
+
+	s.visitors++
+	s.names[key] = true
+	h.visitors++
+	h.names[key] = true
+}
+
+func (s *sessionStats) summary(now time.Time) sessionStatsFile {
+	f := sessionStatsFile{
+		SchemaVersion: schemaVersion,
+		StartedAt:     s.startedAt,
+		UpdatedAt:     now,
+		Visitors:      s.visitors,
+		UniqueNames:   len(s.names),
+		Hours:         []hourStatsFile{},
+	}
+	for hour, h := range s.hours {
+		f.Hours = append(f.Hours, hourStatsFile{Hour: hour, Visitors: h.visitors, UniqueNames: len(h.names)})
+	}
+	sort.Slice(f.Hours, func(i, j int) bool { return f.Hours[i].Hour.Before(f.Hours[j].Hour) })
+	return f
+}
+
+func (s *sessionStats) write(path string, now time.Time) error {
+	data, err := json.MarshalIndent(s.summary(now), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("could not write session stats: %w", err)
+	}
+	return nil
+}
+
+// runSession greets visitors one after the other until the input is
+// closed. Problems with a single visitor (an empty or rejected name) are
+// reported and the session carries on, suppressed names are skipped
+// quietly. The stats file, if any, is
+// rewritten after every visitor so an interrupted session loses nothing.
+// It returns the number of visitors greeted.
+func runSession(ctx context.Context, r io.Reader, w io.Writer, c config) (int, error) {
+	if c.explain {
+		explain(w, c)
+	}
+	scanner := bufio.NewScanner(r)
+	stats := newSessionStats(time.Now())
+	for {
+		name, err := greetVisitor(ctx, scanner, w, c)
+		if err == io.EOF {
+			return stats.visitors, nil
+		}
+		if ctx.Err() != nil {
+			return stats.visitors, errInterrupted
+		}
+		if err == errSuppressed {
+			continue
+		}
+		if err != nil {
+			if scanner.Err() != nil {
+				return stats.visitors, err
+			}
+			fmt.Fprintln(w, c.messages().errorText(err))
+			continue
+		}
+
+		stats.record(name, time.Now())
+		if c.statsFile != "" {
+			if err := stats.write(c.statsFile, time.Now()); err != nil {
+				return stats.visitors, err
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionStats(t *testing.T) {
+	start := time.Date(2026, 10, 14, 9, 5, 0, 0, time.UTC)
+	s := newSessionStats(start)
+	s.record("Benny", start)
+	s.record("benny", start.Add(10*time.Minute))
+	s.record("Ada", start.Add(20*time.Minute))
+	s.record("Benny", start.Add(time.Hour))
+
+	got := s.summary(start.Add(2 * time.Hour))
+	if got.Visitors != 4 {
+		t.Errorf("expected visitors to be: 4, got: %v\n", got.Visitors)
+	}
+	if got.UniqueNames != 2 {
+		t.Errorf("expected unique names to be: 2, got: %v\n", got.UniqueNames)
+	}
+
+	expected := []hourStatsFile{
+		{Hour: time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC), Visitors: 3, UniqueNames: 2},
+		{Hour: time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC), Visitors: 1, UniqueNames: 1},
+	}
+	if len(got.Hours) != len(expected) {
+		t.Fatalf("expected %v hours, got: %v\n", len(expected), got.Hours)
+	}
+	for i := range expected {
+		if !got.Hours[i].Hour.Equal(expected[i].Hour) || got.Hours[i].Visitors != expected[i].Visitors || got.Hours[i].UniqueNames != expected[i].UniqueNames {
+			t.Errorf("expected hour %v to be: %+v, got: %+v\n", i, expected[i], got.Hours[i])
+		}
+	}
+}
+
+func TestRunSession(t *testing.T) {
+	statsFile := filepath.Join(t.TempDir(), "stats.json")
+	c := config{numTimes: 1, session: true, statsFile: statsFile}
+	prompt := "Your name please? Press the return key when done.\n"
+
+	byteBuf := new(bytes.Buffer)
+	visitors, err := runSession(context.Background(), strings.NewReader("Benny\n\nAda\nbenny\n"), byteBuf, c)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if visitors != 3 {
+		t.Errorf("expected visitors to be: 3, got: %v\n", visitors)
+	}
+
+	expected := prompt + "Nice to meet you Benny\n" +
+		prompt + "you didn't enter your name\n" +
+		prompt + "Nice to meet you Ada\n" +
+		prompt + "Nice to meet you benny\n" +
+		prompt
+	if got := byteBuf.String(); got != expected {
+		t.Errorf("expected stdout message to be: %v, got: %v\n", expected, got)
+	}
+
+	data, err := os.ReadFile(statsFile)
+	if err != nil {
+		t.Fatalf("expected stats file to be written, got: %v\n", err)
+	}
+	var stats sessionStatsFile
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatalf("expected valid json, got: %v\n", err)
+	}
+	if stats.Visitors != 3 || stats.UniqueNames != 2 {
+		t.Errorf("expected 3 visitors and 2 unique names, got: %v and %v\n", stats.Visitors, stats.UniqueNames)
+	}
+}
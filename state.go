@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// stateDir is a directory the tool keeps files in between runs.
+type stateDir struct {
+	kind string
+	path string
+}
+
+// stateDirs are the config directory, with the config file and exported
+// assets, and the data directory, with the history.
+func stateDirs() []stateDir {
+	return []stateDir{
+		{kind: "config", path: userConfigDir()},
+		{kind: "data", path: userDataDir()},
+	}
+}
+
+func newStateFlagSet(c *config) *flag.FlagSet {
+	fs := newHelpFlagSet(c)
+	fs.BoolVar(&c.force, "yes", c.force, "with clean, delete without asking first")
+	return fs
+}
+
+// parseStateArgs parses "state path", "state size" and "state clean",
+// options may come before or after the action.
+func parseStateArgs(args []string) (config, error) {
+	c := config{command: "state"}
+	fs := newStateFlagSet(&c)
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	rest := fs.Args()
+	if len(rest) > 0 {
+		c.stateAction = rest[0]
+		if err := fs.Parse(rest[1:]); err != nil {
+			return config{}, err
+		}
+		rest = fs.Args()
+	}
+	if c.printUsage {
+		return config{printUsage: true, command: "state"}, nil
+	}
+	switch c.stateAction {
+	case "path", "size", "clean":
+	case "":
+		return config{}, errors.New("expected path, size or clean")
+	default:
+		return config{}, fmt.Errorf("unknown action %q, expected path, size or clean", c.stateAction)
+	}
+	if len(rest) != 0 {
+		return config{}, ErrInvalidArgCount
+	}
+	return c, nil
+}
+
+func runState(r io.Reader, w io.Writer, c config) error {
+	dirs := stateDirs()
+	for _, d := range dirs {
+		if d.path == "" {
+			return errors.New("no home directory to keep state in")
+		}
+	}
+	if c.stateAction == "clean" {
+		return cleanData(r, w, userDataDir(), c.force)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, d := range dirs {
+		if c.stateAction == "path" {
+			fmt.Fprintf(tw, "%s\t%s\n", d.kind, d.path)
+			continue
+		}
+		size, err := dirSize(d.path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.kind, formatSize(size), d.path)
+	}
+	return tw.Flush()
+}
+
+// dataFiles are the files the tool keeps in userDataDir. state clean
+// deletes only these, as the directory can be shared with the config,
+// with $XDG_DATA_HOME and $XDG_CONFIG_HOME set the same.
+var dataFiles = []string{historyFileName}
+
+// cleanData deletes the dataFiles in dir, and with them the history,
+// after asking on w unless yes is set. dir itself goes too if that
+// leaves it empty. The config file and assets are left alone, they only
+// hold what the user put there.
+func cleanData(r io.Reader, w io.Writer, dir string, yes bool) error {
+	var found []string
+	var size int64
+	for _, name := range dataFiles {
+		path := filepath.Join(dir, name)
+		fi, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not clean %s: %w", dir, err)
+		}
+		found = append(found, path)
+		size += fi.Size()
+	}
+	if len(found) == 0 {
+		_, err := fmt.Fprintln(w, "Nothing to clean.")
+		return err
+	}
+	if !yes {
+		fmt.Fprintf(w, "Delete the greeting history in %s (%s)? [y/N]\n", dir, formatSize(size))
+		scanner := bufio.NewScanner(r)
+		scanner.Scan()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+			_, err := fmt.Fprintln(w, "Nothing deleted.")
+			return err
+		}
+	}
+	for _, path := range found {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("could not clean %s: %w", dir, err)
+		}
+	}
+	// fails, as it should, if anything else is kept there
+	os.Remove(dir)
+	_, err := fmt.Fprintf(w, "Deleted the greeting history in %s.\n", dir)
+	return err
+}
+
+// dirSize adds up the sizes of the files under dir, 0 if it doesn't
+// exist yet.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not measure %s: %w", dir, err)
+	}
+	return size, nil
+}
+
+// formatSize is n bytes in B, KiB or MiB, like du -h.
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KiB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1024*1024))
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStateArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		c    config
+		err  error
+	}{
+		{args: []string{"path"}, c: config{command: "state", stateAction: "path"}},
+		{args: []string{"size"}, c: config{command: "state", stateAction: "size"}},
+		{args: []string{"clean", "--yes"}, c: config{command: "state", stateAction: "clean", force: true}},
+		{args: []string{}, err: errors.New("expected path, size or clean")},
+		{args: []string{"purge"}, err: errors.New(`unknown action "purge", expected path, size or clean`)},
+		{args: []string{"size", "data"}, err: ErrInvalidArgCount},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"state"}, tc.args...))
+		if (err == nil) != (tc.err == nil) || (err != nil && err.Error() != tc.err.Error()) {
+			t.Errorf("%v: expected error: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if tc.err == nil && !reflect.DeepEqual(c, tc.c) {
+			t.Errorf("%v: expected %+v, got: %+v\n", tc.args, tc.c, c)
+		}
+	}
+}
+
+func TestRunState(t *testing.T) {
+	configHome, dataHome := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	writeConfigFile(t, configHome, "name-cli/config.yaml", "times: 3\n")
+
+	out := new(bytes.Buffer)
+	if err := runState(nil, out, config{stateAction: "path"}); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	expected := "config  " + filepath.Join(configHome, "name-cli") + "\ndata    " + filepath.Join(dataHome, "name-cli") + "\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s\n", expected, out.String())
+	}
+
+	// the data directory is only made by the first greeting
+	out.Reset()
+	if err := runState(nil, out, config{stateAction: "size"}); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	expected = "config  9 B  " + filepath.Join(configHome, "name-cli") + "\ndata    0 B  " + filepath.Join(dataHome, "name-cli") + "\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s\n", expected, out.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(configHome, "name-cli", "big"), make([]byte, 3*1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if size, err := dirSize(filepath.Join(configHome, "name-cli")); err != nil || formatSize(size) != "3.0 KiB" {
+		t.Errorf("expected 3.0 KiB, got: %v, %v\n", formatSize(size), err)
+	}
+}
+
+func TestStateClean(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	dir := filepath.Join(dataHome, "name-cli")
+
+	tests := []struct {
+		answer  string
+		yes     bool
+		output  string
+		removed bool
+	}{
+		{answer: "\n", output: "Nothing deleted.\n"},
+		{answer: "no\n", output: "Nothing deleted.\n"},
+		{answer: "y\n", output: "Deleted the greeting history in " + dir + ".\n", removed: true},
+		{yes: true, output: "Deleted the greeting history in " + dir + ".\n", removed: true},
+	}
+
+	for _, tc := range tests {
+		if err := openHistory().add(historyEntry{Name: "Benny", Count: 1}); err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		if err := runState(strings.NewReader(tc.answer), out, config{stateAction: "clean", force: tc.yes}); err != nil {
+			t.Fatalf("%q: expected nil error, got: %v\n", tc.answer, err)
+		}
+		if !strings.HasSuffix(out.String(), tc.output) {
+			t.Errorf("%q: expected output to end with %q, got: %q\n", tc.answer, tc.output, out.String())
+		}
+		if asked := strings.Contains(out.String(), "[y/N]"); asked == tc.yes {
+			t.Errorf("%q: expected to be asked: %v, got: %q\n", tc.answer, !tc.yes, out.String())
+		}
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) != tc.removed {
+			t.Errorf("%q: expected removed: %v, got: %v\n", tc.answer, tc.removed, err)
+		}
+	}
+
+	out := new(bytes.Buffer)
+	if err := runState(strings.NewReader(""), out, config{stateAction: "clean"}); err != nil || out.String() != "Nothing to clean.\n" {
+		t.Errorf("expected nothing to clean, got: %q, %v\n", out.String(), err)
+	}
+}
+
+// TestStateCleanSharedDir checks clean leaves the config alone when it is
+// kept in the same directory as the history.
+func TestStateCleanSharedDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_DATA_HOME", home)
+	writeConfigFile(t, home, "name-cli/config.yaml", "times: 3\n")
+	writeConfigFile(t, home, "name-cli/assets/denylist.txt", "grumpy\n")
+	if err := openHistory().add(historyEntry{Name: "Benny", Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runState(nil, new(bytes.Buffer), config{stateAction: "clean", force: true}); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	for _, name := range []string{"config.yaml", "assets/denylist.txt"} {
+		if _, err := os.Stat(filepath.Join(home, "name-cli", name)); err != nil {
+			t.Errorf("expected %s to survive clean, got: %v\n", name, err)
+		}
+	}
+	if entries, err := openHistory().entries(); err != nil || len(entries) != 0 {
+		t.Errorf("expected the history to be gone, got: %+v, %v\n", entries, err)
+	}
+}
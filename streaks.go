@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// streak is how often and on how many days in a row a name was greeted,
+// worked out from the history.
+type streak struct {
+	name      string // as last greeted
+	current   int    // days in a row up to today, or yesterday if not yet today
+	longest   int
+	greetings int // the runs that greeted the name, whatever their count
+	last      time.Time
+}
+
+// greetingMilestones are the numbers of greetings --show-streak cheers.
+var greetingMilestones = []int{10, 25, 50, 100, 250, 500, 1000}
+
+// streaks works out the streak of every name in entries, names compared
+// like the allowlist does, and days in the time zone of now. They are
+// sorted by the current streak, longest first, and then by name.
+func streaks(entries []historyEntry, now time.Time) []streak {
+	byName := map[string]*streak{}
+	days := map[string][]time.Time{}
+	for _, e := range entries {
+		key := normalizeName(e.Name)
+		s, ok := byName[key]
+		if !ok {
+			s = &streak{}
+			byName[key] = s
+		}
+		s.greetings++
+		if !e.Time.Before(s.last) {
+			s.name, s.last = strings.TrimSpace(e.Name), e.Time
+		}
+		days[key] = append(days[key], day(e.Time.In(now.Location())))
+	}
+
+	today := day(now)
+	list := []streak{}
+	for key, s := range byName {
+		d := days[key]
+		sort.Slice(d, func(i, j int) bool { return d[i].Before(d[j]) })
+		run := 0
+		for i := range d {
+			switch {
+			case i > 0 && d[i].Equal(d[i-1]):
+				continue
+			case i > 0 && d[i].Equal(d[i-1].AddDate(0, 0, 1)):
+				run++
+			default:
+				run = 1
+			}
+			if run > s.longest {
+				s.longest = run
+			}
+		}
+		if last := d[len(d)-1]; last.Equal(today) || last.Equal(today.AddDate(0, 0, -1)) {
+			s.current = run
+		}
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].current != list[j].current {
+			return list[i].current > list[j].current
+		}
+		return normalizeName(list[i].name) < normalizeName(list[j].name)
+	})
+	return list
+}
+
+// day is the midnight starting the day of t.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// note is what --show-streak prints after the greeting, like
+// "(5-day streak!)", or "" if there is nothing to cheer yet.
+func (s streak) note() string {
+	cheers := []string{}
+	if s.current > 1 {
+		cheers = append(cheers, fmt.Sprintf("%d-day streak", s.current))
+	}
+	for _, m := range greetingMilestones {
+		if s.greetings == m {
+			// all of them end in 0 or 5
+			cheers = append(cheers, fmt.Sprintf("%dth greeting", m))
+		}
+	}
+	if len(cheers) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(cheers, ", ") + "!)"
+}
+
+// streakNote returns the note for name from the history, which already
+// has the greeting just made.
+func streakNote(name string) (string, error) {
+	h := openHistory()
+	if h == nil {
+		return "", nil
+	}
+	entries, err := h.entries()
+	if err != nil {
+		return "", err
+	}
+	for _, s := range streaks(entries, time.Now()) {
+		if normalizeName(s.name) == normalizeName(name) {
+			return s.note(), nil
+		}
+	}
+	return "", nil
+}
+
+// parseStatsArgs parses "stats streaks", the one view so far.
+func parseStatsArgs(args []string) (config, error) {
+	c := config{command: "stats"}
+	rest, err := parseHelpArgs(&c, args)
+	if err != nil {
+		return config{}, err
+	}
+	if c.printUsage {
+		return c, nil
+	}
+	if len(rest) == 0 {
+		return config{}, errors.New("expected streaks")
+	}
+	if rest[0] != "streaks" {
+		return config{}, fmt.Errorf("unknown view %q, expected streaks", rest[0])
+	}
+	if len(rest) != 1 {
+		return config{}, ErrInvalidArgCount
+	}
+	c.statsAction = rest[0]
+	return c, nil
+}
+
+func runStats(w io.Writer, c config) error {
+	h := openHistory()
+	if h == nil {
+		return errors.New("no home directory to keep the history in")
+	}
+	entries, err := h.entries()
+	if err != nil {
+		return err
+	}
+	return listStreaks(w, streaks(entries, time.Now()))
+}
+
+// listStreaks writes a line per name with its streaks, greetings and
+// when it was last greeted.
+func listStreaks(w io.Writer, list []streak) error {
+	if len(list) == 0 {
+		_, err := fmt.Fprintln(w, "No greetings yet.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTREAK\tLONGEST\tGREETINGS\tLAST")
+	for _, s := range list {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", s.name, s.current, s.longest, s.greetings, s.last.Local().Format("2006-01-02"))
+	}
+	return tw.Flush()
+}
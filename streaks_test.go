@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreaks(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	entries := []historyEntry{
+		// four days in a row after a gap, twice today
+		{Name: "Benny", Time: daysAgo(9)},
+		{Name: "benny", Time: daysAgo(3)},
+		{Name: "Benny", Time: daysAgo(2)},
+		{Name: "Benny", Time: daysAgo(1)},
+		{Name: "Benny", Time: daysAgo(0).Add(-time.Hour)},
+		{Name: "Benny", Time: daysAgo(0)},
+		// still on a streak until the end of today
+		{Name: "Ada", Time: daysAgo(2)},
+		{Name: "Ada", Time: daysAgo(1)},
+		// three days in a row, long ago
+		{Name: "Grace", Time: daysAgo(30)},
+		{Name: "Grace", Time: daysAgo(29)},
+		{Name: "Grace", Time: daysAgo(28)},
+	}
+
+	expected := []streak{
+		{name: "Benny", current: 4, longest: 4, greetings: 6, last: daysAgo(0)},
+		{name: "Ada", current: 2, longest: 2, greetings: 2, last: daysAgo(1)},
+		{name: "Grace", current: 0, longest: 3, greetings: 3, last: daysAgo(28)},
+	}
+	if got := streaks(entries, now); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got: %+v\n", expected, got)
+	}
+	if got := streaks(nil, now); len(got) != 0 {
+		t.Errorf("expected no streaks without a history, got: %+v\n", got)
+	}
+}
+
+func TestStreakNote(t *testing.T) {
+	tests := []struct {
+		s    streak
+		note string
+	}{
+		{s: streak{current: 1, greetings: 3}},
+		{s: streak{current: 5, greetings: 7}, note: "(5-day streak!)"},
+		{s: streak{current: 1, greetings: 10}, note: "(10th greeting!)"},
+		{s: streak{current: 25, greetings: 25}, note: "(25-day streak, 25th greeting!)"},
+	}
+
+	for _, tc := range tests {
+		if note := tc.s.note(); note != tc.note {
+			t.Errorf("%+v: expected %q, got: %q\n", tc.s, tc.note, note)
+		}
+	}
+}
+
+func TestParseStatsArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		c    config
+		err  error
+	}{
+		{args: []string{"streaks"}, c: config{command: "stats", statsAction: "streaks"}},
+		{args: []string{"--help"}, c: config{command: "stats", printUsage: true}},
+		{args: []string{}, err: errors.New("expected streaks")},
+		{args: []string{"badges"}, err: errors.New(`unknown view "badges", expected streaks`)},
+		{args: []string{"streaks", "Benny"}, err: ErrInvalidArgCount},
+	}
+
+	for _, tc := range tests {
+		c, err := parseArgs(append([]string{"stats"}, tc.args...))
+		if (err == nil) != (tc.err == nil) || (err != nil && err.Error() != tc.err.Error()) {
+			t.Errorf("%v: expected error: %v, got: %v\n", tc.args, tc.err, err)
+		}
+		if tc.err == nil && !reflect.DeepEqual(c, tc.c) {
+			t.Errorf("%v: expected %+v, got: %+v\n", tc.args, tc.c, c)
+		}
+	}
+}
+
+func TestListStreaks(t *testing.T) {
+	at := time.Date(2026, 10, 15, 9, 30, 0, 0, time.Local)
+	out := new(bytes.Buffer)
+	listStreaks(out, []streak{{name: "Benny", current: 4, longest: 4, greetings: 6, last: at}, {name: "Grace", longest: 3, greetings: 3, last: at.AddDate(0, 0, -28)}})
+	expected := "NAME   STREAK  LONGEST  GREETINGS  LAST\n" +
+		"Benny  4       4        6          2026-10-15\n" +
+		"Grace  0       3        3          2026-09-17\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s\n", expected, out.String())
+	}
+}
+
+func TestShowStreak(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	h := openHistory()
+	yesterday := time.Now().AddDate(0, 0, -1)
+	for i := 0; i < 8; i++ {
+		if err := h.add(historyEntry{SchemaVersion: schemaVersion, Time: yesterday.AddDate(0, 0, -i), Name: "Benny", Count: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := new(bytes.Buffer)
+	c := withDefaults(config{numTimes: 1, name: "Benny", showStreak: true})
+	if err := runCmd(context.Background(), strings.NewReader(""), out, c); err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if expected := "Nice to meet you Benny\n(9-day streak!)\n"; out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s\n", expected, out.String())
+	}
+
+	for _, c := range []config{
+		{numTimes: 1, showStreak: true, output: outputJSON},
+		{numTimes: 1, showStreak: true, noHistory: true},
+	} {
+		if err := validateArgs(c); err == nil {
+			t.Errorf("%+v: expected an error\n", c)
+		}
+	}
+}
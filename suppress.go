@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// errSuppressed is returned by checkName for names on the suppression
+// list. Batch, session and display runs skip them without a word, the
+// skip only shows up in the audit log.
+var errSuppressed = errors.New("name is on the suppression list")
+
+const (
+	hashPrefix            = "sha256:"
+	defaultSuppressReason = "opted out"
+)
+
+// suppressList maps the sha256 of a normalized name to the reason it is
+// suppressed. Lines are a name or "sha256:<hex>", optionally followed by a
+// bar and the reason, as in "Ada Lovelace | asked not to be greeted".
+// Hashes let a list be shared without writing the names down.
+type suppressList map[string]string
+
+func loadSuppressList(path, enc string) (suppressList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read suppression list: %w", err)
+	}
+	defer f.Close()
+
+	s := suppressList{}
+	scanner := bufio.NewScanner(decodeInput(f, enc))
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		reason := defaultSuppressReason
+		if i := strings.Index(entry, "|"); i >= 0 {
+			entry, reason = strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+		}
+		key := nameHash(entry)
+		if strings.HasPrefix(entry, hashPrefix) {
+			key = strings.ToLower(strings.TrimPrefix(entry, hashPrefix))
+			if b, err := hex.DecodeString(key); err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("could not read suppression list: line %d: invalid sha256 hash", line)
+			}
+		}
+		s[key] = reason
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read suppression list: %w", err)
+	}
+	return s, nil
+}
+
+// match returns the reason name is suppressed, if it is.
+func (s suppressList) match(name string) (string, bool) {
+	reason, ok := s[nameHash(name)]
+	return reason, ok
+}
+
+// nameHash is the hex sha256 of the normalized name, which is how names
+// are written to suppression lists and the audit log.
+func nameHash(name string) string {
+	sum := sha256.Sum256([]byte(normalizeName(name)))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditEntry is a line of the --audit-log file. The name is only ever
+// recorded as a hash.
+type auditEntry struct {
+	SchemaVersion int       `json:"schema_version"`
+	Time          time.Time `json:"time"`
+	Event         string    `json:"event"`
+	NameSHA256    string    `json:"name_sha256"`
+	Reason        string    `json:"reason"`
+}
+
+// suppress checks name against the suppression list of c and, if it is
+// listed, records the skip in the audit log and returns errSuppressed.
+func suppress(c config, name string) error {
+	s, err := loadSuppressList(c.suppressFile, c.inputEncoding)
+	if err != nil {
+		return err
+	}
+	reason, ok := s.match(name)
+	if !ok {
+		return nil
+	}
+	if c.auditFile != "" {
+		e := auditEntry{
+			SchemaVersion: schemaVersion,
+			Time:          time.Now(),
+			Event:         "suppressed",
+			NameSHA256:    nameHash(name),
+			Reason:        reason,
+		}
+		if err := appendAudit(c.auditFile, e); err != nil {
+			return err
+		}
+	}
+	return errSuppressed
+}
+
+// appendAudit adds e to the audit log as a line of JSON.
+func appendAudit(path string, e auditEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSuppressList(t *testing.T) {
+	tests := []struct {
+		contents string
+		name     string
+		reason   string
+		matched  bool
+		err      string
+	}{
+		{contents: "Ada Lovelace\n", name: "  ada   LOVELACE", reason: defaultSuppressReason, matched: true},
+		{contents: "# opt-outs\nAda Lovelace | asked at the desk\n", name: "Ada Lovelace", reason: "asked at the desk", matched: true},
+		{contents: hashPrefix + nameHash("Benny") + " | GDPR request 42\n", name: "benny", reason: "GDPR request 42", matched: true},
+		{contents: "Ada Lovelace\n", name: "Ada", matched: false},
+		{contents: "Ada\nsha256:abc | typo\n", err: "could not read suppression list: line 2: invalid sha256 hash"},
+	}
+
+	for _, tc := range tests {
+		path := filepath.Join(t.TempDir(), "optout.txt")
+		if err := os.WriteFile(path, []byte(tc.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		s, err := loadSuppressList(path, "")
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("expected error: %v, got: %v\n", tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("expected nil error, got: %v\n", err)
+		}
+		reason, ok := s.match(tc.name)
+		if ok != tc.matched || reason != tc.reason {
+			t.Errorf("%q: expected (%q, %v), got: (%q, %v)\n", tc.name, tc.reason, tc.matched, reason, ok)
+		}
+	}
+}
+
+func TestRunBatchSuppressed(t *testing.T) {
+	dir := t.TempDir()
+	suppressFile := filepath.Join(dir, "optout.txt")
+	auditFile := filepath.Join(dir, "audit.jsonl")
+	if err := os.WriteFile(suppressFile, []byte("Ada | opted out by email\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config{numTimes: 1, stdinBatch: true, suppressFile: suppressFile, auditFile: auditFile}
+	out := new(bytes.Buffer)
+	greeted, err := runBatch(context.Background(), strings.NewReader("Benny\nAda\nGrace\n"), out, c)
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v\n", err)
+	}
+	if greeted != 2 {
+		t.Errorf("expected 2 names to be greeted, got: %v\n", greeted)
+	}
+	if expected := "Nice to meet you Benny\nNice to meet you Grace\n"; out.String() != expected {
+		t.Errorf("expected stdout message to be: %q, got: %q\n", expected, out.String())
+	}
+
+	data, err := os.ReadFile(auditFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Ada") {
+		t.Errorf("expected the audit log not to contain the name, got: %s\n", data)
+	}
+	var e auditEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatalf("expected one line of json, got: %v\n", err)
+	}
+	if e.Event != "suppressed" || e.Reason != "opted out by email" || e.NameSHA256 != nameHash("ada") {
+		t.Errorf("unexpected audit entry: %+v\n", e)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/jordanengstrom/name-cli-app.git/pkg/greeter"
+)
+
+// defaultTemplate is the greeting when --template isn't given.
+const defaultTemplate = "Nice to meet you {{.Name}}"
+
+// parseTemplate parses a --template, see greeter.ParseTemplate, with num
+// and date formatting in the language of m.
+func parseTemplate(text string, m messages) (*template.Template, error) {
+	t, err := greeter.ParseTemplate(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %v", err)
+	}
+	return t.Funcs(m.templateFuncs()), nil
+}
+
+// customTemplate reports whether c asks for something else than the
+// default greeting.
+func (c config) customTemplate() bool {
+	return c.template != "" && c.template != defaultTemplate
+}
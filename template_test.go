@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jordanengstrom/name-cli-app.git/pkg/greeter"
+)
+
+func TestGreetWithTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		output   string
+	}{
+		{template: "", output: "Nice to meet you Benny\nNice to meet you Benny\n"},
+		{template: defaultTemplate, output: "Nice to meet you Benny\nNice to meet you Benny\n"},
+		{template: "{{.Index}}/{{.Count}} Hello, {{.Name}}!", output: "1/2 Hello, Benny!\n2/2 Hello, Benny!\n"},
+		{template: `{{if eq .Index .Count}}Bye{{else}}Hi{{end}} {{.Name}}`, output: "Hi Benny\nBye Benny\n"},
+		{template: `{{.Time.Year | printf "%T"}}`, output: "int\nint\n"},
+	}
+
+	for _, tc := range tests {
+		byteBuf := new(bytes.Buffer)
+		if err := greetUser(context.Background(), config{numTimes: 2, template: tc.template}, "Benny", byteBuf); err != nil {
+			t.Fatalf("%q: expected nil error, got: %v\n", tc.template, err)
+		}
+		if byteBuf.String() != tc.output {
+			t.Errorf("%q: expected %q, got: %q\n", tc.template, tc.output, byteBuf.String())
+		}
+	}
+}
+
+func TestGreetWithTemplateLargeCount(t *testing.T) {
+	byteBuf := new(bytes.Buffer)
+	numTimes := 2*greeter.BufSize/len("Hi Benny\n") + 1
+	if err := greetUser(context.Background(), config{numTimes: numTimes, template: "Hi {{.Name}}"}, "Benny", byteBuf); err != nil {
+		t.Fatal(err)
+	}
+	if byteBuf.String() != strings.Repeat("Hi Benny\n", numTimes) {
+		t.Errorf("expected %d greetings, got %d bytes\n", numTimes, byteBuf.Len())
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		err      error
+	}{
+		{template: "Hi {{.Name}}"},
+		{template: "Hi {{.Name", err: errors.New("invalid --template: template: greeting:1: unclosed action")},
+		{template: "Hi {{.Nmae}}", err: errors.New(`invalid --template: template: greeting:1:5: executing "greeting" at <.Nmae>: can't evaluate field Nmae in type greeter.Greeting`)},
+	}
+
+	for _, tc := range tests {
+		err := validateArgs(withDefaults(config{numTimes: 1, template: tc.template}))
+		if tc.err != nil && (err == nil || err.Error() != tc.err.Error()) {
+			t.Errorf("%q: expected error to be: %v, got: %v\n", tc.template, tc.err, err)
+		}
+		if tc.err == nil && err != nil {
+			t.Errorf("%q: expected nil error, got: %v\n", tc.template, err)
+		}
+	}
+}
+
+func TestTemplateFromEnv(t *testing.T) {
+	t.Setenv("NAME_CLI_GREETING", "Hey {{.Name}}")
+	c, err := parseArgs([]string{"1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.template != "Hey {{.Name}}" || c.origin["template"] != "$NAME_CLI_GREETING" {
+		t.Errorf("expected the template from NAME_CLI_GREETING, got: %q from %q\n", c.template, c.origin["template"])
+	}
+
+	// the variable named after the flag wins
+	t.Setenv("NAME_CLI_TEMPLATE", "Hello {{.Name}}")
+	c, _ = parseArgs([]string{"1"})
+	if c.template != "Hello {{.Name}}" {
+		t.Errorf("expected the template from NAME_CLI_TEMPLATE, got: %q\n", c.template)
+	}
+}
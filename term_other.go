@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package main
+
+import "os"
+
+// ttySize isn't supported here, terminalSize falls back to the
+// environment.
+func ttySize(f *os.File) (cols, rows int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ttySize asks the terminal f is attached to for its size.
+func ttySize(f *os.File) (cols, rows int, ok bool) {
+	var ws struct{ row, col, xpixel, ypixel uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 || ws.col == 0 || ws.row == 0 {
+		return 0, 0, false
+	}
+	return int(ws.col), int(ws.row), true
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	usageText     = "text"
+	usageMarkdown = "markdown"
+	usageMan      = "man"
+)
+
+const usageDescription = "A greeter application which prints the name you entered <count> number of times."
+
+// usageEnvironment explains where options come from besides the command
+// line, see resolveConfig.
+const usageEnvironment = "Every option can also be set with an environment variable named after it, such as NAME_CLI_TIMES for --times, or in config.yaml in the config directory shown by name-cli state path. The command line wins over the environment, which wins over the config file."
+
+// printUsage writes the help in format, with the headings and
+// descriptions from the catalog m.
+func printUsage(w io.Writer, format string, m messages) {
+	if format == "" || format == usageText {
+		fmt.Fprint(w, renderUsage(os.Args[0], usageText, m))
+		return
+	}
+	fmt.Fprint(w, renderUsage("name-cli", format, m))
+}
+
+// usageFlag is a flag as shown in the usage text, with its short alias
+// folded in.
+type usageFlag struct {
+	flags string // e.g. "-n, --times <count>"
+	usage string
+	def   string
+}
+
+// usageFlags lists the flags registered on fs with their defaults, in the
+// order the FlagSet keeps them (sorted by name).
+func usageFlags(fs *flag.FlagSet) []usageFlag {
+	shorts := map[string]string{}
+	for short, long := range flagAliases {
+		shorts[long] = short
+	}
+
+	flags := []usageFlag{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := flagAliases[f.Name]; ok || hiddenFlags[f.Name] {
+			return
+		}
+		arg, usage := flag.UnquoteUsage(f)
+		u := usageFlag{flags: "--" + f.Name, usage: usage}
+		if short, ok := shorts[f.Name]; ok {
+			u.flags = "-" + short + ", " + u.flags
+		}
+		if arg != "" {
+			u.flags += " <" + arg + ">"
+		}
+		if !isZeroDefault(f) {
+			u.def = f.DefValue
+		}
+		flags = append(flags, u)
+	})
+	return flags
+}
+
+func (u usageFlag) usageWithDefault() string {
+	if u.def == "" {
+		return u.usage
+	}
+	if strings.Contains(u.def, " ") {
+		return fmt.Sprintf("%s (default %q)", u.usage, u.def)
+	}
+	return fmt.Sprintf("%s (default %s)", u.usage, u.def)
+}
+
+func renderUsage(prog, format string, m messages) string {
+	switch format {
+	case usageMarkdown:
+		return renderMarkdownUsage(prog, m)
+	case usageMan:
+		return renderManUsage(prog, m)
+	}
+	return renderTextUsage(prog, m)
+}
+
+func renderTextUsage(prog string, m messages) string {
+	var b strings.Builder
+	usage := m.usage + ": "
+	fmt.Fprintf(&b, "%s%s [options] <count>\n", usage, prog)
+	for _, cmd := range commands[1:] {
+		fmt.Fprintf(&b, "%*s%s\n", len([]rune(usage)), "", strings.TrimSpace(prog+" "+cmd.name+" "+cmd.args))
+	}
+	fmt.Fprintf(&b, "\n%s\n\n%s (%s):\n", m.description, m.commands, fmt.Sprintf(m.commandsHint, "'"+prog+" <command> --help'"))
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-10s  %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(&b, "\n%s:\n", m.options)
+	writeUsageFlags(&b, usageFlags(greetFlagSet()))
+	fmt.Fprintf(&b, "\n%s\n", m.environment)
+
+	// the full list is available with the examples command, keep the
+	// help short by showing one example per topic
+	fmt.Fprintf(&b, "\n%s (%s):\n", m.examples, fmt.Sprintf(m.examplesHint, "'"+prog+" examples'", strings.Join(exampleTopics(), ", ")))
+	shown := map[string]bool{}
+	for _, e := range usageExamples {
+		if shown[e.topic] {
+			continue
+		}
+		shown[e.topic] = true
+		fmt.Fprintf(&b, "  %s %s\n      %s\n", prog, e.args, e.usage)
+	}
+	return b.String()
+}
+
+// writeUsageFlags writes an aligned list of flags for the text usage.
+func writeUsageFlags(b *strings.Builder, flags []usageFlag) {
+	width := 0
+	for _, u := range flags {
+		if n := len(u.flags); n > width {
+			width = n
+		}
+	}
+	for _, u := range flags {
+		fmt.Fprintf(b, "  %-*s  %s\n", width, u.flags, u.usageWithDefault())
+	}
+}
+
+func greetFlagSet() *flag.FlagSet {
+	c := defaultConfig()
+	return newFlagSet(&c)
+}
+
+func renderMarkdownUsage(prog string, m messages) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s\n\n## %s\n\n    %s [options] <count>\n", prog, m.description, m.usage, prog)
+	for _, cmd := range commands[1:] {
+		fmt.Fprintf(&b, "    %s\n", strings.TrimSpace(prog+" "+cmd.name+" "+cmd.args))
+	}
+
+	fmt.Fprintf(&b, "\n## %s\n\n%s.\n\n", m.commands, capitalize(fmt.Sprintf(m.commandsHint, "`"+prog+" <command> --help`")))
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "- `%s`: %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(&b, "\n## %s\n\n| Option | Description | Default |\n| --- | --- | --- |\n", m.options)
+	for _, u := range usageFlags(greetFlagSet()) {
+		def := ""
+		if u.def != "" {
+			def = "`" + u.def + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", strings.ReplaceAll(u.flags, "|", `\|`), strings.ReplaceAll(u.usage, "|", `\|`), def)
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", m.environment)
+
+	fmt.Fprintf(&b, "\n## %s\n", m.examples)
+	for _, e := range usageExamples {
+		fmt.Fprintf(&b, "\n%s:\n\n    %s %s\n", capitalize(e.usage), prog, e.args)
+	}
+	return b.String()
+}
+
+// renderManUsage keeps the usual English section names of man pages and
+// only translates the text.
+func renderManUsage(prog string, m messages) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1\n.SH NAME\n%s \\- greet a name a number of times\n", strings.ToUpper(prog), prog)
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n.B %s\n%s\n", prog, manSynopsis(commands[0].args))
+	for _, cmd := range commands[1:] {
+		fmt.Fprintf(&b, ".br\n.B %s %s\n", prog, cmd.name)
+		if cmd.args != "" {
+			fmt.Fprintf(&b, "%s\n", manSynopsis(cmd.args))
+		}
+	}
+	fmt.Fprintf(&b, ".SH DESCRIPTION\n%s\n.SH COMMANDS\n", manEscape(m.description))
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", cmd.name, manEscape(cmd.summary))
+	}
+	b.WriteString(".SH OPTIONS\n")
+	for _, u := range usageFlags(greetFlagSet()) {
+		fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", manEscape(u.flags), manEscape(u.usageWithDefault()))
+	}
+	fmt.Fprintf(&b, ".SH ENVIRONMENT\n%s\n", manEscape(m.environment))
+	b.WriteString(".SH EXAMPLES\n")
+	for _, e := range usageExamples {
+		fmt.Fprintf(&b, ".TP\n\\fB%s %s\\fR\n%s\n", prog, manEscape(e.args), manEscape(e.usage))
+	}
+	return b.String()
+}
+
+var manSynopsisArg = regexp.MustCompile(`--[a-z-]+|<([^>]+)>|\[([a-z][a-z ]*)\]`)
+
+// manSynopsis formats the arguments of a usage line for the man page,
+// flags in bold and placeholders in italics.
+func manSynopsis(args string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range manSynopsisArg.FindAllStringSubmatchIndex(args, -1) {
+		b.WriteString(manEscape(args[last:m[0]]))
+		switch {
+		case m[2] >= 0:
+			b.WriteString(`\fI` + manEscape(args[m[2]:m[3]]) + `\fR`)
+		case m[4] >= 0:
+			b.WriteString(`[\fI` + manEscape(args[m[4]:m[5]]) + `\fR]`)
+		default:
+			b.WriteString(`\fB` + manEscape(args[m[0]:m[1]]) + `\fR`)
+		}
+		last = m[1]
+	}
+	b.WriteString(manEscape(args[last:]))
+	return b.String()
+}
+
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUsageListsEveryOption(t *testing.T) {
+	for _, format := range []string{usageText, usageMarkdown, usageMan} {
+		shown := map[string]bool{}
+		usage := renderUsage("name-cli", format, catalogs[defaultLang])
+		c := defaultConfig()
+		newFlagSet(&c).VisitAll(func(f *flag.Flag) {
+			if hiddenFlags[f.Name] {
+				if strings.Contains(usage, "--"+f.Name) {
+					t.Errorf("expected %v usage not to mention the hidden --%v\n", format, f.Name)
+				}
+				return
+			}
+			name := "--" + f.Name
+			if _, ok := flagAliases[f.Name]; ok {
+				name = "-" + f.Name + ", "
+			}
+			if format == usageMan {
+				name = manEscape(name)
+			}
+			if !strings.Contains(usage, name) {
+				t.Errorf("expected %v usage to mention %v\n", format, name)
+			}
+		})
+		for _, e := range usageExamples {
+			// the text usage only shows the first example of each topic
+			if format == usageText && shown[e.topic] {
+				continue
+			}
+			shown[e.topic] = true
+			args := e.args
+			if format == usageMan {
+				args = manEscape(args)
+			}
+			if !strings.Contains(usage, args) {
+				t.Errorf("expected %v usage to contain the example %q\n", format, e.args)
+			}
+		}
+	}
+}
+
+func TestRenderTextUsage(t *testing.T) {
+	usage := renderTextUsage("name-cli", catalogs[defaultLang])
+	expectedLines := []string{
+		"Usage: name-cli [options] <count>",
+		"  -h, --help                          show this help and exit",
+		"  -n, --times <count>                 number of times to greet, instead of giving the count as an argument",
+		"  --match-threshold <0-1>             how close (0-1) a name must be to an allowlist entry to be suggested (default 0.8)",
+		`  --fallback <message>                message shown instead of the greeting for names not on the allowlist (default "sorry, you are not on the guest list")`,
+		"  --usage-format <text|markdown|man>  print this help as text|markdown|man and exit (default text)",
+		"  name-cli 3",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(usage, line+"\n") {
+			t.Errorf("expected usage to contain the line %q, got:\n%v\n", line, usage)
+		}
+	}
+}
+
+func TestPrintUsageFormats(t *testing.T) {
+	byteBuf := new(bytes.Buffer)
+	printUsage(byteBuf, "", catalogs[defaultLang])
+	if byteBuf.String() != renderUsage(os.Args[0], usageText, catalogs[defaultLang]) {
+		t.Errorf("expected default usage to be the text usage")
+	}
+
+	byteBuf.Reset()
+	printUsage(byteBuf, usageMarkdown, catalogs[defaultLang])
+	if !strings.HasPrefix(byteBuf.String(), "# name-cli\n") {
+		t.Errorf("expected markdown usage, got: %v\n", byteBuf.String())
+	}
+
+	byteBuf.Reset()
+	printUsage(byteBuf, usageMan, catalogs[defaultLang])
+	if !strings.HasPrefix(byteBuf.String(), ".TH NAME-CLI 1\n") {
+		t.Errorf("expected a man page, got: %v\n", byteBuf.String())
+	}
+}
+
+func TestManEscape(t *testing.T) {
+	tests := map[string]string{
+		"--report":   `\-\-report`,
+		`a\b`:        `a\\b`,
+		".hidden":    `\&.hidden`,
+		"plain text": "plain text",
+	}
+	for input, expected := range tests {
+		if got := manEscape(input); got != expected {
+			t.Errorf("expected %q to be escaped as: %q, got: %q\n", input, expected, got)
+		}
+	}
+}